@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/config"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+var exchangeRatesCmd = &cobra.Command{
+	Use:   "exchange-rates",
+	Short: "Manage the exchange rates used for display prices",
+}
+
+var importExchangeRatesCmd = &cobra.Command{
+	Use:   "import [file.csv]",
+	Short: "Import currency,rate[,rounding_step] rows, updating listed currencies and keeping the others",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		configEnv, err := cmd.Flags().GetString("env")
+		if err != nil {
+			panic(err.Error())
+		}
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			panic(err.Error())
+		}
+		defer file.Close()
+
+		rates, err := models.ParseExchangeRatesCSV(file)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		configFileName := fmt.Sprintf("%s.%s", config.DefaultConfigName, configEnv)
+		cfg := config.NewConfig(configFileName, config.DefaultConfigLocation)
+		db, err := app.NewDatabase(cfg.Private.Database)
+		if err != nil {
+			panic(err.Error())
+		}
+		defer db.Close()
+
+		tx := db.MustBegin()
+		for _, rate := range rates {
+			if err := rate.Save(tx); err != nil {
+				_ = tx.Rollback()
+				panic(err.Error())
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			panic(err.Error())
+		}
+
+		for _, rate := range rates {
+			fmt.Printf("%s: %v (rounded to %v)\n", rate.Currency, rate.Rate, rate.RoundingStep)
+		}
+		fmt.Printf("Imported %d exchange rates\n", len(rates))
+	},
+}
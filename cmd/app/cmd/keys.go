@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the keys tokens and signed URLs are signed with",
+}
+
+var generateKeyCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a signing key and print it as a JWK",
+	Long: "Generate an RSA signing key and print it as a JWK, ready to be set as private.signing_key. " +
+		"To rotate keys, move the current signing key to private.verification_keys so that what it " +
+		"signed stays valid, and drop it from there once the longest lived of those has expired.",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		bits, err := cmd.Flags().GetInt("bits")
+		if err != nil {
+			panic(err.Error())
+		}
+
+		key, err := app.GenerateSigningKey(bits)
+		if err != nil {
+			panic(err.Error())
+		}
+		b, err := json.Marshal(key)
+		if err != nil {
+			panic(err.Error())
+		}
+		fmt.Fprintf(os.Stderr, "kid: %s\n", key.KeyID())
+		fmt.Println(string(b))
+	},
+}
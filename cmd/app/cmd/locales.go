@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/config"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+var localesCmd = &cobra.Command{
+	Use:   "locales",
+	Short: "Manage the locale messages stored in the database",
+}
+
+var importLocalesCmd = &cobra.Command{
+	Use:   "import [locale.json...]",
+	Short: "Store the messages of locale files which differ from the shipped ones",
+	Long: "Store the messages of locale files named after their language, for example id-ID.json, " +
+		"which differ from the locale files shipped with the server. Stored messages equal to the " +
+		"shipped ones are removed.",
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, db := configAndDatabase(cmd)
+		defer db.Close()
+
+		localizer := app.NewLocalizer(cfg.Private.Localizer)
+		tx := db.MustBegin()
+		for _, path := range args {
+			lang := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			if !localizer.Supports(lang) {
+				_ = tx.Rollback()
+				panic(fmt.Sprintf("%s: %s is not a supported language", path, lang))
+			}
+
+			messages, err := app.ReadLocaleFile(path)
+			if err != nil {
+				_ = tx.Rollback()
+				panic(err.Error())
+			}
+			shipped := localizer.Messages(lang)
+
+			stored, unchanged := 0, []string{}
+			for id, message := range messages {
+				if message == shipped[id] {
+					unchanged = append(unchanged, id)
+					continue
+				}
+				m := models.LocaleMessage{Locale: lang, MessageID: id, Message: message}
+				if err := m.Save(tx); err != nil {
+					_ = tx.Rollback()
+					panic(err.Error())
+				}
+				stored++
+			}
+			if err := models.DeleteLocaleMessages(tx, lang, unchanged...); err != nil {
+				_ = tx.Rollback()
+				panic(err.Error())
+			}
+			fmt.Printf("%s: stored %d messages, %d match the locale file\n", lang, stored, len(unchanged))
+		}
+		if err := tx.Commit(); err != nil {
+			panic(err.Error())
+		}
+	},
+}
+
+var exportLocalesCmd = &cobra.Command{
+	Use:   "export [directory]",
+	Short: "Write the locale files merged with the stored messages into a directory",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, db := configAndDatabase(cmd)
+		defer db.Close()
+
+		overrides, err := models.GetLocaleMessageOverrides(db)
+		if err != nil {
+			panic(err.Error())
+		}
+		localizer := app.NewLocalizer(cfg.Private.Localizer)
+		if err := localizer.Reload(overrides); err != nil {
+			panic(err.Error())
+		}
+
+		if err := os.MkdirAll(args[0], 0o755); err != nil {
+			panic(err.Error())
+		}
+		for _, lang := range localizer.Languages() {
+			path := filepath.Join(args[0], lang+".json")
+			if err := writeLocaleFile(path, localizer.Messages(lang)); err != nil {
+				panic(err.Error())
+			}
+			fmt.Printf("%s: %d messages written to %s\n", lang, len(localizer.Messages(lang)), path)
+		}
+	},
+}
+
+func configAndDatabase(cmd *cobra.Command) (*config.Config, *sqlx.DB) {
+	configEnv, err := cmd.Flags().GetString("env")
+	if err != nil {
+		panic(err.Error())
+	}
+
+	configFileName := fmt.Sprintf("%s.%s", config.DefaultConfigName, configEnv)
+	cfg := config.NewConfig(configFileName, config.DefaultConfigLocation)
+	db, err := app.NewDatabase(cfg.Private.Database)
+	if err != nil {
+		panic(err.Error())
+	}
+	return cfg, db
+}
+
+// writeLocaleFile writes messages sorted by ID, in the layout of the shipped
+// locale files.
+func writeLocaleFile(path string, messages map[string]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(messages)
+}
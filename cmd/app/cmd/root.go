@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -16,6 +17,26 @@ func init() {
 	rootCmd.AddCommand(serveCmd)
 	serveCmd.Flags().String("env", "", "Which environment this server will run on")
 
+	rootCmd.AddCommand(exchangeRatesCmd)
+	exchangeRatesCmd.AddCommand(importExchangeRatesCmd)
+	importExchangeRatesCmd.Flags().String("env", "", "Which environment config to use")
+
+	rootCmd.AddCommand(localesCmd)
+	localesCmd.AddCommand(importLocalesCmd, exportLocalesCmd)
+	importLocalesCmd.Flags().String("env", "", "Which environment config to use")
+	exportLocalesCmd.Flags().String("env", "", "Which environment config to use")
+
+	rootCmd.AddCommand(systemsCmd)
+	systemsCmd.AddCommand(createSystemCmd, listSystemsCmd, rotateSystemSecretCmd, revokeSystemCmd)
+	systemsCmd.PersistentFlags().String("env", "", "Which environment config to use")
+	createSystemCmd.Flags().String("url", "", "URL of the system")
+	createSystemCmd.Flags().StringSlice("scope", nil, "Scopes granted to the system, repeat or separate with commas")
+	rotateSystemSecretCmd.Flags().Duration("grace", 24*time.Hour, "How long the previous secret keeps working")
+
+	rootCmd.AddCommand(keysCmd)
+	keysCmd.AddCommand(generateKeyCmd)
+	generateKeyCmd.Flags().Int("bits", 2048, "Size of the RSA key")
+
 }
 
 func Execute() {
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"gopkg.in/guregu/null.v4"
+)
+
+var systemsCmd = &cobra.Command{
+	Use:   "systems",
+	Short: "Manage the partner systems allowed to get access tokens",
+}
+
+var createSystemCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Register a system and print its secret, which cannot be read again",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_, db := configAndDatabase(cmd)
+		defer db.Close()
+
+		systemURL, err := cmd.Flags().GetString("url")
+		if err != nil {
+			panic(err.Error())
+		}
+
+		scopes, err := cmd.Flags().GetStringSlice("scope")
+		if err != nil {
+			panic(err.Error())
+		}
+		for _, scope := range scopes {
+			if !models.IsSystemScope(scope) {
+				panic(fmt.Sprintf("unknown scope %s, expected one of %s", scope, strings.Join(models.SystemScopes, ", ")))
+			}
+		}
+
+		secret := models.GenerateSecretKey()
+		system := models.System{Name: args[0], URL: systemURL, PlainSecretKey: null.StringFrom(secret)}
+		tx := db.MustBegin()
+		if err := system.Insert(tx); err != nil {
+			_ = tx.Rollback()
+			panic(err.Error())
+		}
+		if err := models.SetSystemScopes(tx, system.ID, scopes); err != nil {
+			_ = tx.Rollback()
+			panic(err.Error())
+		}
+		if err := tx.Commit(); err != nil {
+			panic(err.Error())
+		}
+		fmt.Printf("id:     %s\nsecret: %s\nscopes: %s\n", system.ID, secret, strings.Join(scopes, " "))
+	},
+}
+
+var listSystemsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the registered systems",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, db := configAndDatabase(cmd)
+		defer db.Close()
+
+		systems, err := models.GetSystems(db)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(out, "ID\tNAME\tURL\tSTATUS")
+		for _, system := range systems {
+			status := "active"
+			if system.IsRevoked() {
+				status = "revoked " + time.Unix(system.RevokedAt.Int64, 0).Format(time.RFC3339)
+			} else if system.PreviousSecretExpiresAt.ValueOrZero() > time.Now().Unix() {
+				status = "rotating until " + time.Unix(system.PreviousSecretExpiresAt.Int64, 0).Format(time.RFC3339)
+			}
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", system.ID, system.Name, system.URL, status)
+		}
+		_ = out.Flush()
+	},
+}
+
+var rotateSystemSecretCmd = &cobra.Command{
+	Use:   "rotate [system id]",
+	Short: "Give a system a new secret, the old one keeps working for the grace period",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_, db := configAndDatabase(cmd)
+		defer db.Close()
+
+		grace, err := cmd.Flags().GetDuration("grace")
+		if err != nil {
+			panic(err.Error())
+		}
+		system := findSystem(db, args[0])
+		if system.IsRevoked() {
+			panic(fmt.Sprintf("%s has been revoked", system.ID))
+		}
+
+		secret := system.RotateSecretKey(grace)
+		if err := system.Update(db); err != nil {
+			panic(err.Error())
+		}
+		fmt.Printf("secret: %s\n", secret)
+		if system.PreviousSecretExpiresAt.Valid {
+			fmt.Printf("the previous secret works until %s\n", time.Unix(system.PreviousSecretExpiresAt.Int64, 0).Format(time.RFC3339))
+		}
+	},
+}
+
+var revokeSystemCmd = &cobra.Command{
+	Use:   "revoke [system id]",
+	Short: "Stop a system from getting tokens and revoke the tokens it holds",
+	Long: "Stop a system from getting tokens and revoke the tokens it holds. Running servers " +
+		"reject the revoked tokens once they reload their revocation list.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_, db := configAndDatabase(cmd)
+		defer db.Close()
+
+		system := findSystem(db, args[0])
+		system.Revoke()
+
+		tx := db.MustBegin()
+		if err := system.Update(tx); err != nil {
+			_ = tx.Rollback()
+			panic(err.Error())
+		}
+		if err := models.RevokeSystemAccessTokens(tx, system.ID); err != nil {
+			_ = tx.Rollback()
+			panic(err.Error())
+		}
+		if err := tx.Commit(); err != nil {
+			panic(err.Error())
+		}
+		fmt.Printf("%s revoked\n", system.ID)
+	},
+}
+
+func findSystem(db *sqlx.DB, id string) *models.System {
+	system, found, err := models.GetSystemByID(db, id)
+	if err != nil {
+		panic(err.Error())
+	} else if !found {
+		panic(fmt.Sprintf("system %s does not exist", id))
+	}
+	return system
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/filestore"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/logger"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/messaging"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/recommendation"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/scheduler"
 )
 
 type Registry struct {
@@ -27,8 +29,10 @@ type Registry struct {
 	Log             *logger.Logger
 	MessageProducer *nsq.Producer
 	Messaging       messaging.Clients
+	Recommender     *recommendation.Recommender
+	Scheduler       *scheduler.Scheduler
 	SigningKey      jwk.RSAPrivateKey
-	VerifyKey       jwk.RSAPublicKey
+	VerifyKeys      jwk.Set
 }
 
 func NewRegistry(config *config.Config, appName string) *Registry {
@@ -37,7 +41,7 @@ func NewRegistry(config *config.Config, appName string) *Registry {
 		panic(err.Error())
 	}
 
-	secretKey, publicKey, err := NewSigningKey(config.Private)
+	secretKey, verifyKeys, err := NewSigningKey(config.Private)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -53,7 +57,7 @@ func NewRegistry(config *config.Config, appName string) *Registry {
 	}
 
 	authModule := NewAuthModule(config.Private.Auth)
-	authModule.Init(db, c, publicKey)
+	authModule.Init(db, c, verifyKeys)
 
 	loggerModule, err := NewLogger(config.Private.Log, config.Public.Debug, appName)
 	if err != nil {
@@ -69,6 +73,8 @@ func NewRegistry(config *config.Config, appName string) *Registry {
 	}
 
 	localizerModule := NewLocalizer(config.Private.Localizer)
+	recommender := NewRecommender(config.Public.Recommendation, c)
+	schedulerModule := NewScheduler(loggerModule)
 
 	return &Registry{
 		AppURL: config.Public.AppURL,
@@ -83,7 +89,9 @@ func NewRegistry(config *config.Config, appName string) *Registry {
 		Log:             loggerModule,
 		Localizer:       localizerModule,
 		MessageProducer: nsqProducer,
+		Recommender:     recommender,
+		Scheduler:       schedulerModule,
 		SigningKey:      secretKey,
-		VerifyKey:       publicKey,
+		VerifyKeys:      verifyKeys,
 	}
 }
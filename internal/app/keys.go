@@ -1,26 +1,93 @@
 package app
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"fmt"
 
+	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/oklog/ulid/v2"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/config"
 )
 
-func NewSigningKey(config *config.PrivateConfig) (jwk.RSAPrivateKey, jwk.RSAPublicKey, error) {
-	rawKey := config.SigningKey
-	private, err := jwk.ParseKey([]byte(rawKey))
+// NewSigningKey loads the key tokens are signed with along with the public
+// keys they are verified against: its own and the ones of the retired keys,
+// which keep the tokens and signed URLs they issued valid until those expire.
+func NewSigningKey(config *config.PrivateConfig) (jwk.RSAPrivateKey, jwk.Set, error) {
+	private, err := parseKey(config.SigningKey)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, fmt.Errorf("signing key: %w", err)
 	}
-	if _, ok := private.(jwk.RSAPrivateKey); !ok {
+	signingKey, ok := private.(jwk.RSAPrivateKey)
+	if !ok {
 		return nil, nil, fmt.Errorf("signing key must be an RSA private key")
 	}
 
-	public, err := private.PublicKey()
+	keys := jwk.NewSet()
+	for i, raw := range append([]string{config.SigningKey}, config.VerificationKeys...) {
+		key, err := parseKey(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("verification key %d: %w", i, err)
+		}
+		public, err := key.PublicKey()
+		if err != nil {
+			return nil, nil, fmt.Errorf("verification key %d: %w", i, err)
+		}
+		if _, ok := public.(jwk.RSAPublicKey); !ok {
+			return nil, nil, fmt.Errorf("verification key %d must be an RSA key", i)
+		}
+		if _, found := keys.LookupKeyID(public.KeyID()); found {
+			return nil, nil, fmt.Errorf("verification key %d: kid %s is used twice", i, public.KeyID())
+		}
+		if err := keys.AddKey(public); err != nil {
+			return nil, nil, fmt.Errorf("verification key %d: %w", i, err)
+		}
+	}
+
+	return signingKey, keys, nil
+}
+
+// GenerateSigningKey makes a new RSA signing key, named like the keys
+// already in use.
+func GenerateSigningKey(bits int) (jwk.RSAPrivateKey, error) {
+	raw, err := rsa.GenerateKey(rand.Reader, bits)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := key.Set(jwk.KeyIDKey, "sig_"+ulid.Make().String()); err != nil {
+		return nil, err
 	}
+	if err := key.Set(jwk.AlgorithmKey, jwa.RS256); err != nil {
+		return nil, err
+	}
+	if err := key.Set(jwk.KeyUsageKey, jwk.ForSignature); err != nil {
+		return nil, err
+	}
+	return key.(jwk.RSAPrivateKey), nil
+}
 
-	return private.(jwk.RSAPrivateKey), public.(jwk.RSAPublicKey), nil
+// parseKey reads a JWK. Keys without kid are named after their thumbprint,
+// so that tokens always say which key signed them, and keys without alg get
+// RS256 as it is the only algorithm in use.
+func parseKey(raw string) (jwk.Key, error) {
+	key, err := jwk.ParseKey([]byte(raw))
+	if err != nil {
+		return nil, err
+	}
+	if key.KeyID() == "" {
+		if err := jwk.AssignKeyID(key); err != nil {
+			return nil, err
+		}
+	}
+	if key.Algorithm().String() == "" {
+		if err := key.Set(jwk.AlgorithmKey, jwa.RS256); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
 }
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/config"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/authentication"
+)
+
+func TestNewSigningKey(t *testing.T) {
+	marshal := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	active, err := GenerateSigningKey(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired, err := GenerateSigningKey(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateSigningKey(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingKey, keys, err := NewSigningKey(&config.PrivateConfig{
+		SigningKey:       marshal(active),
+		VerificationKeys: []string{marshal(retired)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signingKey.KeyID() != active.KeyID() {
+		t.Errorf("want the active key to sign; got %s", signingKey.KeyID())
+	}
+	if keys.Len() != 2 {
+		t.Fatalf("want 2 verification keys; got %d", keys.Len())
+	}
+	for i := 0; i < keys.Len(); i++ {
+		key, _ := keys.Key(i)
+		if _, err := key.PublicKey(); err != nil || key.KeyID() == "" {
+			t.Errorf("want named public keys; got %v", key)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(marshal(key)), &fields); err != nil {
+			t.Fatal(err)
+		}
+		if _, found := fields["d"]; found {
+			t.Errorf("want no private part published; got %v", fields)
+		}
+	}
+
+	token, err := jwt.NewBuilder().Subject("admins:1").Expiration(time.Now().Add(time.Hour)).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(key any) []byte {
+		signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	signed := sign(signingKey)
+	msg, err := jws.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := msg.Signatures()[0].ProtectedHeaders().KeyID(); kid != active.KeyID() {
+		t.Errorf("want kid %s in the header; got %q", active.KeyID(), kid)
+	}
+
+	// The raw key has no kid to put in the header
+	var rawRetired any
+	if err := retired.Raw(&rawRetired); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		signed []byte
+		valid  bool
+	}{
+		{"active key", signed, true},
+		{"retired key", sign(retired), true},
+		{"retired key without kid", sign(rawRetired), true},
+		{"unknown key", sign(other), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.Parse(tt.signed, authentication.VerifyKeySet(keys))
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("want valid %v; got %v", tt.valid, err)
+			}
+		})
+	}
+}
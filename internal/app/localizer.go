@@ -2,37 +2,164 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"sync"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/config"
 	"golang.org/x/text/language"
 )
 
+// DefaultLanguage is the language used when a request asks for nothing the
+// localizer supports, and the fallback of untranslated messages.
+const DefaultLanguage = "en-US"
+
 type Localizer struct {
+	config config.LocalizerConfig
+
+	mu        sync.RWMutex
 	localizer map[string]*i18n.Localizer
+	messages  map[string]map[string]string
+	languages []string
+	matcher   language.Matcher
 }
 
 func NewLocalizer(config config.LocalizerConfig) *Localizer {
-	bundle := i18n.NewBundle(language.English)
-	bundle.RegisterUnmarshalFunc("json", json.Unmarshal)
+	localizer := &Localizer{config: config}
+	if err := localizer.Reload(nil); err != nil {
+		panic(err.Error())
+	}
 
+	// The first language of a matcher is what it falls back to
+	localizer.languages = []string{DefaultLanguage}
 	for _, supportedLang := range config.SupportedLanguages {
-		filepath := fmt.Sprintf("%v/%v.json", config.Directory, supportedLang)
-		bundle.MustLoadMessageFile(filepath)
+		if supportedLang != DefaultLanguage {
+			localizer.languages = append(localizer.languages, supportedLang)
+		}
+	}
+	tags := make([]language.Tag, 0, len(localizer.languages))
+	for _, lang := range localizer.languages {
+		tags = append(tags, language.Make(lang))
 	}
+	localizer.matcher = language.NewMatcher(tags)
 
-	localizer := &Localizer{
-		localizer: make(map[string]*i18n.Localizer),
+	return localizer
+}
+
+// ErrPluralMessage is returned for locale messages with plural forms, which
+// the message catalog and its overrides cannot hold.
+var ErrPluralMessage = errors.New("plural forms are not supported")
+
+// ReadLocaleFile reads the messages of a locale file, keyed by message ID.
+func ReadLocaleFile(path string) (map[string]string, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadLocaleFile][ReadFile]%w", err)
 	}
-	for _, supportedLang := range config.SupportedLanguages {
-		localizer.localizer[supportedLang] = i18n.NewLocalizer(bundle, supportedLang)
+	file, err := i18n.ParseMessageFileBytes(buf, path, map[string]i18n.UnmarshalFunc{"json": json.Unmarshal})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadLocaleFile][ParseMessageFileBytes]%w", err)
 	}
 
-	return localizer
+	messages := make(map[string]string, len(file.Messages))
+	for _, m := range file.Messages {
+		if m.Zero != "" || m.One != "" || m.Two != "" || m.Few != "" || m.Many != "" {
+			return nil, fmt.Errorf("[ReadLocaleFile]%w: %s", ErrPluralMessage, m.ID)
+		}
+		messages[m.ID] = m.Other
+	}
+	return messages, nil
+}
+
+// Reload reads the locale files again and rebuilds the bundle, with overrides,
+// messages keyed by language then message ID, replacing the file messages.
+// Requests keep being served by the previous bundle until it is swapped.
+func (l *Localizer) Reload(overrides map[string]map[string]string) error {
+	bundle := i18n.NewBundle(language.English)
+	localizers := make(map[string]*i18n.Localizer, len(l.config.SupportedLanguages))
+	catalogs := make(map[string]map[string]string, len(l.config.SupportedLanguages))
+
+	for _, supportedLang := range l.config.SupportedLanguages {
+		filepath := fmt.Sprintf("%v/%v.json", l.config.Directory, supportedLang)
+		messages, err := ReadLocaleFile(filepath)
+		if err != nil {
+			return fmt.Errorf("[Localizer.Reload]%w", err)
+		}
+		for id, message := range overrides[supportedLang] {
+			messages[id] = message
+		}
+
+		i18nMessages := make([]*i18n.Message, 0, len(messages))
+		for id, message := range messages {
+			i18nMessages = append(i18nMessages, &i18n.Message{ID: id, Other: message})
+		}
+		if err := bundle.AddMessages(language.Make(supportedLang), i18nMessages...); err != nil {
+			return fmt.Errorf("[Localizer.Reload][AddMessages]%w", err)
+		}
+		catalogs[supportedLang] = messages
+	}
+	for _, supportedLang := range l.config.SupportedLanguages {
+		localizers[supportedLang] = i18n.NewLocalizer(bundle, supportedLang)
+	}
+
+	l.mu.Lock()
+	l.localizer = localizers
+	l.messages = catalogs
+	l.mu.Unlock()
+	return nil
+}
+
+// Languages returns the supported languages, DefaultLanguage first.
+func (l *Localizer) Languages() []string {
+	return append([]string{}, l.languages...)
+}
+
+// Messages returns a copy of the messages of lang, keyed by message ID.
+func (l *Localizer) Messages(lang string) map[string]string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	messages := make(map[string]string, len(l.messages[lang]))
+	for id, message := range l.messages[lang] {
+		messages[id] = message
+	}
+	return messages
+}
+
+// Supports reports whether lang is one of the configured languages.
+func (l *Localizer) Supports(lang string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, ok := l.localizer[lang]
+	return ok
+}
+
+// Negotiate returns the supported language closest to the first preference
+// that matches any, each preference being a language tag or an
+// Accept-Language header value. Without a match it returns DefaultLanguage,
+// the same fallback as GetLocalizedLanguage.
+func (l *Localizer) Negotiate(preferences ...string) string {
+	for _, preference := range preferences {
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		_, index, confidence := l.matcher.Match(tags...)
+		if confidence != language.No {
+			return l.languages[index]
+		}
+	}
+	return DefaultLanguage
 }
 
 func (l *Localizer) GetLocalizedLanguage(langTag string, messageID string, templateData map[string]any) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	usedLocalizer := l.localizer[langTag]
 	if usedLocalizer == nil {
 		usedLocalizer = l.localizer["en-US"]
@@ -59,6 +186,9 @@ func (l *Localizer) GetLocalizedLanguage(langTag string, messageID string, templ
 }
 
 func (l *Localizer) GetLocalizedDefaultAndPrefMessage(langTag string, messageID string, templateData map[string]any) map[string]string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	messages := make(map[string]string)
 	defaultTranslatedMessage, _ := l.localizer["en-US"].Localize(&i18n.LocalizeConfig{
 		DefaultMessage: &i18n.Message{
@@ -83,3 +213,19 @@ func (l *Localizer) GetLocalizedDefaultAndPrefMessage(langTag string, messageID
 
 	return messages
 }
+
+// Missing returns the sorted IDs of the DefaultLanguage messages which have
+// no translation in lang.
+func (l *Localizer) Missing(lang string) []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	missing := []string{}
+	for id := range l.messages[DefaultLanguage] {
+		if l.messages[lang][id] == "" {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
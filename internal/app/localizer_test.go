@@ -0,0 +1,120 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/config"
+)
+
+func TestLocalizerNegotiate(t *testing.T) {
+	l := NewLocalizer(config.LocalizerConfig{
+		Directory:          "../../locales",
+		SupportedLanguages: []string{"en-US", "id-ID", "vi-VN", "zh-Hant-TW"},
+	})
+
+	tests := []struct {
+		preferences []string
+		want        string
+	}{
+		{[]string{"zh-TW,zh;q=0.9,en;q=0.8"}, "zh-Hant-TW"},
+		{[]string{"id"}, "id-ID"},
+		{[]string{"vi-VN"}, "vi-VN"},
+		{[]string{"fr-FR"}, "en-US"},
+		{[]string{""}, "en-US"},
+		{[]string{"", "vi"}, "vi-VN"},
+		{[]string{"id", "vi"}, "id-ID"},
+	}
+
+	for _, tt := range tests {
+		if got := l.Negotiate(tt.preferences...); got != tt.want {
+			t.Errorf("Negotiate(%q): want %v; got %v", tt.preferences, tt.want, got)
+		}
+	}
+}
+
+func TestLocalizerFallback(t *testing.T) {
+	l := NewLocalizer(config.LocalizerConfig{
+		Directory:          "../../locales",
+		SupportedLanguages: []string{"en-US", "id-ID", "vi-VN", "zh-Hant-TW"},
+	})
+
+	tests := []struct {
+		lang      string
+		messageID string
+		data      map[string]any
+		want      string
+	}{
+		{"zh-Hant-TW", "error.not_found", nil, "找不到請求的資源"},
+		{"id-ID", "validation.validation_length_too_long", map[string]any{"max": 50}, "panjang tidak boleh lebih dari 50"},
+		{"vi-VN", "test", nil, "test"},
+		{"fr-FR", "error.not_found", nil, "Requested resource cannot be found"},
+		{"id-ID", "missing", nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := l.GetLocalizedLanguage(tt.lang, tt.messageID, tt.data); got != tt.want {
+			t.Errorf("GetLocalizedLanguage(%v, %v): want %q; got %q", tt.lang, tt.messageID, tt.want, got)
+		}
+	}
+}
+
+func TestLocalizerReload(t *testing.T) {
+	l := NewLocalizer(config.LocalizerConfig{
+		Directory:          "../../locales",
+		SupportedLanguages: []string{"en-US", "id-ID", "vi-VN", "zh-Hant-TW"},
+	})
+
+	missing := l.Missing("vi-VN")
+	if len(missing) != 1 || missing[0] != "test" {
+		t.Fatalf("Missing(vi-VN): want [test]; got %v", missing)
+	}
+
+	err := l.Reload(map[string]map[string]string{
+		"vi-VN": {"test": "thử nghiệm"},
+		"id-ID": {"error.not_found": "Tidak ditemukan"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := l.GetLocalizedLanguage("vi-VN", "test", nil); got != "thử nghiệm" {
+		t.Errorf("vi-VN test: want %q; got %q", "thử nghiệm", got)
+	}
+	if got := l.GetLocalizedLanguage("id-ID", "error.not_found", nil); got != "Tidak ditemukan" {
+		t.Errorf("id-ID error.not_found: want %q; got %q", "Tidak ditemukan", got)
+	}
+	if got := l.Messages("id-ID")["error.forbidden"]; got == "" {
+		t.Errorf("the locale file messages should be kept")
+	}
+	if missing := l.Missing("vi-VN"); len(missing) != 0 {
+		t.Errorf("Missing(vi-VN): want none; got %v", missing)
+	}
+}
+
+func TestReadLocaleFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("reads messages", func(t *testing.T) {
+		path := filepath.Join(dir, "en-US.json")
+		if err := os.WriteFile(path, []byte(`{"greeting": "Hello"}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		messages, err := ReadLocaleFile(path)
+		if err != nil || messages["greeting"] != "Hello" {
+			t.Errorf("want greeting %q; got %v, %v", "Hello", messages, err)
+		}
+	})
+
+	t.Run("rejects plural forms", func(t *testing.T) {
+		path := filepath.Join(dir, "id-ID.json")
+		if err := os.WriteFile(path, []byte(`{"phones": {"one": "{{.Count}} phone", "other": "{{.Count}} phones"}}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadLocaleFile(path); !errors.Is(err, ErrPluralMessage) {
+			t.Errorf("want %v; got %v", ErrPluralMessage, err)
+		}
+	})
+}
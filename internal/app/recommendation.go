@@ -0,0 +1,16 @@
+package app
+
+import (
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/config"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/cache"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/recommendation"
+)
+
+func NewRecommender(cfg config.RecommendationConfig, c cache.Cache) *recommendation.Recommender {
+	return recommendation.New(c, recommendation.Weights{
+		Brand: cfg.BrandWeight,
+		Tags:  cfg.TagWeight,
+		Price: cfg.PriceWeight,
+		Specs: cfg.SpecWeight,
+	}, cfg.Limit, cfg.PoolSize)
+}
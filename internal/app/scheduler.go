@@ -0,0 +1,12 @@
+package app
+
+import (
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/logger"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/scheduler"
+)
+
+func NewScheduler(log *logger.Logger) *scheduler.Scheduler {
+	return scheduler.New(func(name string, err error) {
+		log.Errorf("[Scheduler][%s] %v", name, err)
+	})
+}
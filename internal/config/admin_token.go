@@ -0,0 +1,9 @@
+package config
+
+type AdminTokenConfig struct {
+	// AccessTTLSeconds is the lifetime of the access tokens issued to admins.
+	AccessTTLSeconds int `mapstructure:"access_ttl_seconds"`
+	// RefreshTTLSeconds is how long an admin can stay idle, every refresh
+	// issues a refresh token valid this long again.
+	RefreshTTLSeconds int `mapstructure:"refresh_ttl_seconds"`
+}
@@ -0,0 +1,8 @@
+package config
+
+type CampaignConfig struct {
+	// Resolution decides which campaign wins when several target the same
+	// phone: "priority" picks the highest priority, "best_price" the lowest
+	// resulting price.
+	Resolution string `mapstructure:"resolution"`
+}
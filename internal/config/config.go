@@ -61,6 +61,9 @@ type PublicConfig struct {
 	AppURL               string       `mapstructure:"app_url"`
 	PrometheusAPIJobName string       `mapstructure:"prometheus_api_job_name"`
 	Listen               ListenConfig `mapstructure:"listen"`
+	// TrustedProxies are the addresses or CIDR ranges of the proxies in
+	// front of the app, whose X-Real-IP header tells the client address.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 
 	Migration                         MigrationConfig           `mapstructure:"migration"`
 	DBName                            string                    `mapstructure:"-"`
@@ -71,11 +74,20 @@ type PublicConfig struct {
 	MaxRadiusNearestStore             int                       `mapstructure:"max_radius_nearest_store"`
 	MaxBalanceMutation                float64                   `mapstructure:"max_balance_mutation"`
 	MaxOnlineDriverInactiveTimeSecond int                       `mapstructure:"max_online_driver_inactive_time_second"`
+	Recommendation                    RecommendationConfig      `mapstructure:"recommendation"`
+	Scheduler                         SchedulerConfig           `mapstructure:"scheduler"`
+	Campaign                          CampaignConfig            `mapstructure:"campaign"`
+	PriceApproval                     PriceApprovalConfig       `mapstructure:"price_approval"`
+	SystemToken                       SystemTokenConfig         `mapstructure:"system_token"`
+	AdminToken                        AdminTokenConfig          `mapstructure:"admin_token"`
 	NsqConfig                         `mapstructure:"nsq"`
 }
 
 type PrivateConfig struct {
 	SigningKey string `mapstructure:"signing_key"`
+	// VerificationKeys are retired signing keys, only their public part is
+	// used to verify what they signed until it expires.
+	VerificationKeys []string `mapstructure:"verification_keys"`
 
 	Database  DatabaseConfig
 	Storage   StorageConfig
@@ -0,0 +1,8 @@
+package config
+
+type PriceApprovalConfig struct {
+	// ThresholdPercent is the largest price move, in percent of the current
+	// price, that applies without a second admin approving it. Zero disables
+	// the approval workflow.
+	ThresholdPercent float64 `mapstructure:"threshold_percent"`
+}
@@ -0,0 +1,10 @@
+package config
+
+type RecommendationConfig struct {
+	BrandWeight float64 `mapstructure:"brand_weight"`
+	TagWeight   float64 `mapstructure:"tag_weight"`
+	PriceWeight float64 `mapstructure:"price_weight"`
+	SpecWeight  float64 `mapstructure:"spec_weight"`
+	Limit       int     `mapstructure:"limit"`
+	PoolSize    int     `mapstructure:"pool_size"`
+}
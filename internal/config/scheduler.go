@@ -0,0 +1,5 @@
+package config
+
+type SchedulerConfig struct {
+	TickSeconds int `mapstructure:"tick_seconds"`
+}
@@ -0,0 +1,11 @@
+package config
+
+type SystemTokenConfig struct {
+	// TTLSeconds is the lifetime of the access tokens issued to systems.
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// MaxFailedAttempts failed token requests of a client or an IP address
+	// within FailureWindowSeconds block further requests until the window
+	// passes.
+	MaxFailedAttempts    int `mapstructure:"max_failed_attempts"`
+	FailureWindowSeconds int `mapstructure:"failure_window_seconds"`
+}
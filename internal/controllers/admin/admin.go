@@ -0,0 +1,283 @@
+package admin
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/mq"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/permission"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+	"gopkg.in/guregu/null.v4"
+)
+
+type AdminController struct {
+	controllers.Controller
+}
+
+func NewAdminController(app *app.Registry) *AdminController {
+	return &AdminController{controllers.Controller{App: app}}
+}
+
+// GetAdmins pages through the admins, newest first. The next page is read by
+// passing back next_page_cursor as cursor.
+func (c *AdminController) GetAdmins(w http.ResponseWriter, r *http.Request) {
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = defaultPerPage
+	} else if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	lastID := database.UUIDMaxValue
+	lastCreatedAt := int64(math.MaxInt64)
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		createdAt, id, err := database.DecodeCursor(cursor)
+		if err != nil {
+			panic(validation.Errors{"cursor": validation.NewError("invalid_cursor", "cursor is invalid")})
+		}
+		lastID, lastCreatedAt = id, createdAt.Unix()
+	}
+
+	// One more admin than asked tells whether there is a next page
+	admins, err := models.GetAdminBatched(c.App.DB, lastID, lastCreatedAt, perPage+1)
+	if err != nil {
+		panic(err)
+	}
+
+	page := AdminPage{
+		Admins:     admins,
+		Pagination: controllers.PaginationDetail{PerPage: perPage},
+	}
+	if len(admins) > perPage {
+		page.Admins = admins[:perPage]
+		last := page.Admins[perPage-1]
+		page.Pagination.HasNext = true
+		page.Pagination.NextPageCursor = database.EncodeCursor(time.Unix(last.CreatedAt, 0), last.ID)
+	} else if admins == nil {
+		page.Admins = []models.Admin{}
+	}
+
+	if err := responses.JSON(w, http.StatusOK, page); err != nil {
+		panic(err)
+	}
+}
+
+// GetAdmin shows an admin with their role and its permissions.
+func (c *AdminController) GetAdmin(w http.ResponseWriter, r *http.Request) {
+	admin := c.adminFromURL(r)
+	c.respondWithAdmin(w, admin)
+}
+
+func (c *AdminController) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	admin := c.adminFromURL(r)
+	caller, err := controllers.GetAdminFromAuth(c.RequestContext(r).Auth)
+	if err != nil {
+		panic(err)
+	}
+	if admin.ID == caller.ID {
+		panic(httperr.NewErrUnprocessableEntity("cannot_change_own_role", "admins cannot change their own role", nil))
+	}
+
+	var req ChangeRoleRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	role, found, err := models.GetRoleByID(c.App.DB, req.RoleID)
+	if err != nil {
+		panic(err)
+	} else if !found {
+		// Deleted since the request was validated
+		panic(validation.Errors{"role_id": validation.NewError("invalid_role_id", "role does not exist")})
+	}
+	c.ensureCanGrant(caller, role)
+
+	if err := admin.ChangeRole(c.App.DB, role); err != nil {
+		panic(err)
+	}
+
+	c.forgetAdmin(admin.ID)
+	c.publishAdminUpdated(admin.ID)
+	c.respondWithAdmin(w, admin)
+}
+
+// ensureCanGrant refuses to give a role holding permissions the caller does
+// not hold, which would let admins hand out more than they have. Admins who
+// manage roles could give the permissions to a role anyway.
+func (c *AdminController) ensureCanGrant(caller *models.Admin, role *models.Role) {
+	if caller.RoleID.ValueOrZero() == role.ID {
+		return
+	}
+	manager, err := permission.AdminHas(c.App.Cache, c.App.DB, caller, models.PermissionRoleManage)
+	if err != nil {
+		panic(err)
+	} else if manager {
+		return
+	}
+
+	granted, err := permission.RolePermissions(c.App.Cache, c.App.DB, role.ID)
+	if err != nil {
+		panic(err)
+	}
+	held := []string{}
+	if caller.RoleID.ValueOrZero() != "" {
+		held, err = permission.RolePermissions(c.App.Cache, c.App.DB, caller.RoleID.String)
+		if err != nil {
+			panic(err)
+		}
+	}
+	missing := []string{}
+	for _, identifier := range granted {
+		if !slices.Contains(held, identifier) {
+			missing = append(missing, identifier)
+		}
+	}
+	if len(missing) > 0 {
+		panic(httperr.NewErrUnprocessableEntity("role_exceeds_own_permissions", "role holds permissions you do not have", map[string]any{
+			"permissions": missing,
+		}))
+	}
+}
+
+// Deactivate stops an admin from logging in and revokes the tokens they
+// already hold. Admins cannot deactivate themselves.
+func (c *AdminController) Deactivate(w http.ResponseWriter, r *http.Request) {
+	admin := c.adminFromURL(r)
+	if admin.ID == c.RequestContext(r).Auth.UserID() {
+		panic(httperr.NewErrUnprocessableEntity("cannot_deactivate_self", "admins cannot deactivate themselves", nil))
+	}
+
+	if !admin.IsDeactivated() {
+		admin.DeactivatedAt = null.TimeFrom(time.Now())
+		admin.InvalidateTokens()
+		if err := admin.Update(c.App.DB); err != nil {
+			panic(err)
+		}
+	}
+	// Also run for deactivated admins, in case an earlier revocation failed
+	if err := c.App.Auth.RevokeAdmin(admin.ID); err != nil {
+		panic(err)
+	}
+
+	c.forgetAdmin(admin.ID)
+	c.publishAdminUpdated(admin.ID)
+	c.respondWithAdmin(w, admin)
+}
+
+func (c *AdminController) Reactivate(w http.ResponseWriter, r *http.Request) {
+	admin := c.adminFromURL(r)
+
+	if admin.IsDeactivated() {
+		admin.DeactivatedAt = null.Time{}
+		if err := admin.Update(c.App.DB); err != nil {
+			panic(err)
+		}
+		c.forgetAdmin(admin.ID)
+		c.publishAdminUpdated(admin.ID)
+	}
+
+	c.respondWithAdmin(w, admin)
+}
+
+// Logout forces an admin to log in again, every token issued to them so far
+// stops working.
+func (c *AdminController) Logout(w http.ResponseWriter, r *http.Request) {
+	admin := c.adminFromURL(r)
+
+	admin.InvalidateTokens()
+	if err := admin.Update(c.App.DB); err != nil {
+		panic(err)
+	}
+	if err := c.App.Auth.RevokeAdmin(admin.ID); err != nil {
+		panic(err)
+	}
+
+	c.forgetAdmin(admin.ID)
+	c.publishAdminUpdated(admin.ID)
+	c.respondWithAdmin(w, admin)
+}
+
+// GetSessions lists the sessions of an admin.
+func (c *AdminController) GetSessions(w http.ResponseWriter, r *http.Request) {
+	admin := c.adminFromURL(r)
+
+	sessions, err := c.App.Auth.GetAdminSessions(admin.ID, c.RequestContext(r).Auth.TokenID())
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, sessions); err != nil {
+		panic(err)
+	}
+}
+
+// RevokeSession logs an admin out of one of their sessions, the others are
+// left alone.
+func (c *AdminController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	admin := c.adminFromURL(r)
+
+	found, err := c.App.Auth.RevokeAdminSession(admin.ID, chi.URLParam(r, "SessionID"))
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrNotFound)
+	}
+	c.respondWithAdmin(w, admin)
+}
+
+// RevokeOtherSessions logs an admin out of every session but the one making
+// the request, which only matters when it is their own.
+func (c *AdminController) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	admin := c.adminFromURL(r)
+
+	if err := c.App.Auth.RevokeOtherAdminSessions(admin.ID, c.RequestContext(r).Auth.TokenID()); err != nil {
+		panic(err)
+	}
+	c.respondWithAdmin(w, admin)
+}
+
+// forgetAdmin makes the change of an admin apply to their tokens right away.
+// The change is saved already, when the cache fails it applies once the
+// cached state expires.
+func (c *AdminController) forgetAdmin(adminID string) {
+	if err := c.App.Auth.ForgetAdmin(adminID); err != nil {
+		c.App.Log.Errorf("[AdminController.forgetAdmin] %v", err)
+	}
+}
+
+func (c *AdminController) publishAdminUpdated(adminID string) {
+	err := mq.PublishMessage(c.App.MessageProducer, mq.AdminUpdatedTopic, mq.AdminUpdatedMsg{
+		AdminID: adminID,
+	})
+	if err != nil {
+		c.App.Log.Errorf("[AdminController.publishAdminUpdated] %v", err)
+	}
+}
+
+func (c *AdminController) respondWithAdmin(w http.ResponseWriter, admin *models.Admin) {
+	if err := admin.LoadRole(c.App.DB, true); err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, admin); err != nil {
+		panic(err)
+	}
+}
+
+func (c *AdminController) adminFromURL(r *http.Request) *models.Admin {
+	admin, found, err := models.GetAdminByID(c.App.DB, chi.URLParam(r, "AdminID"))
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrNotFound)
+	}
+	return admin
+}
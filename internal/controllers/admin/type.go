@@ -0,0 +1,41 @@
+package admin
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// AdminPage is a page of admins, newest first.
+type AdminPage struct {
+	Admins     []models.Admin               `json:"data"`
+	Pagination controllers.PaginationDetail `json:"pagination"`
+}
+
+type ChangeRoleRequest struct {
+	RoleID string `json:"role_id"`
+}
+
+func (r *ChangeRoleRequest) Authorized(_ *reqdata.Context) bool {
+	return true
+}
+
+func (r *ChangeRoleRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.RoleID, validation.Required, validation.By(func(value interface{}) error {
+			_, found, err := models.GetRoleByID(ctx.App.DB, value.(string))
+			if err != nil {
+				return validation.NewInternalError(err)
+			} else if !found {
+				return validation.NewError("invalid_role_id", "role does not exist")
+			}
+			return nil
+		})),
+	)
+}
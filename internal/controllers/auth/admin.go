@@ -7,9 +7,13 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/oklog/ulid/v2"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/cache"
@@ -18,6 +22,11 @@ import (
 	"gopkg.in/guregu/null.v4"
 )
 
+const (
+	defaultAdminAccessTTL  = 15 * time.Minute
+	defaultAdminRefreshTTL = 7 * 24 * time.Hour
+)
+
 type AuthAdminController struct {
 	controllers.Controller
 }
@@ -49,6 +58,10 @@ func (c *AuthAdminController) LoginByXinchuanAuth(w http.ResponseWriter, r *http
 		panic(err)
 	}
 
+	if exist && admin.IsDeactivated() {
+		panic(httperr.ErrForbidden)
+	}
+
 	if !exist {
 		loop := 0
 		maxLoop := 5
@@ -93,13 +106,10 @@ func (c *AuthAdminController) LoginByXinchuanAuth(w http.ResponseWriter, r *http
 		log.Println("[Admin.LoginByXinchuanAuth] publish updated:", err)
 	}
 
-	resp := controllers.GenerateAccessToken(c.App, admin, 24*time.Hour, controllers.AuthTokenContext{
-		AuthProvider:       "xinchuan-auth",
-		RequestFingerprint: controllers.GetRequestFingerprint(r),
-	}, map[string]any{
-		"via": "xinchuan-auth",
-		"as":  "admin",
-	})
+	resp, err := c.issueTokens(admin, "", r)
+	if err != nil {
+		panic(err)
+	}
 	err = responses.JSON(w, 200, resp)
 	if err != nil {
 		panic(err)
@@ -163,6 +173,17 @@ func (c *AuthAdminController) Logout(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
+	// The refresh tokens descending from the same login go too, or the
+	// session would be back with the next refresh
+	refresh, found, err := models.GetAdminRefreshTokenByAccessTokenID(c.App.DB, id)
+	if err != nil {
+		panic(err)
+	} else if found {
+		if err := c.App.Auth.RevokeAdminRefreshFamily(refresh.FamilyID); err != nil {
+			panic(err)
+		}
+	}
+
 	err = responses.JSON(w, 200, struct {
 		OK bool `json:"ok"`
 	}{
@@ -173,3 +194,155 @@ func (c *AuthAdminController) Logout(w http.ResponseWriter, r *http.Request) {
 	}
 
 }
+
+// Sessions lists the sessions of the admin, the one making the request
+// flagged as current.
+func (c *AuthAdminController) Sessions(w http.ResponseWriter, r *http.Request) {
+	auth := c.RequestContext(r).Auth
+
+	sessions, err := c.App.Auth.GetAdminSessions(auth.UserID(), auth.TokenID())
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, sessions); err != nil {
+		panic(err)
+	}
+}
+
+// RevokeSession logs the admin out of one of their sessions.
+func (c *AuthAdminController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	auth := c.RequestContext(r).Auth
+
+	found, err := c.App.Auth.RevokeAdminSession(auth.UserID(), chi.URLParam(r, "SessionID"))
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrNotFound)
+	}
+	c.respondOK(w)
+}
+
+// RevokeOtherSessions logs the admin out everywhere but the session making
+// the request.
+func (c *AuthAdminController) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	auth := c.RequestContext(r).Auth
+
+	if err := c.App.Auth.RevokeOtherAdminSessions(auth.UserID(), auth.TokenID()); err != nil {
+		panic(err)
+	}
+	c.respondOK(w)
+}
+
+func (c *AuthAdminController) respondOK(w http.ResponseWriter) {
+	err := responses.JSON(w, http.StatusOK, struct {
+		OK bool `json:"ok"`
+	}{
+		OK: true,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Refresh trades a refresh token for a new access token and refresh token.
+// A refresh token is good for one trade, when a used one shows up again it
+// has leaked and the whole family descending from the login is revoked.
+func (c *AuthAdminController) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	refresh, found, err := models.GetAdminRefreshTokenByPlain(c.App.DB, req.RefreshToken)
+	if err != nil {
+		panic(err)
+	} else if !found || refresh.RevokedAt.Valid || refresh.IsExpired() {
+		panic(httperr.ErrUnauthenticated)
+	}
+
+	reused := refresh.UsedAt.Valid
+	if !reused {
+		consumed, err := refresh.MarkUsed(c.App.DB)
+		if err != nil {
+			panic(err)
+		}
+		reused = !consumed
+	}
+	if reused {
+		c.App.Log.Warning(fmt.Sprintf("[AuthAdminController.Refresh] refresh token %s reused, revoking family %s", refresh.ID, refresh.FamilyID))
+		if err := c.App.Auth.RevokeAdminRefreshFamily(refresh.FamilyID); err != nil {
+			panic(err)
+		}
+		panic(httperr.ErrUnauthenticated)
+	}
+
+	state, err := c.App.Auth.GetAdminState(refresh.AdminID)
+	if err != nil {
+		panic(err)
+	}
+	if !state.Exists || state.Deactivated || refresh.CreatedAt <= state.TokensValidAfter {
+		if err := c.App.Auth.RevokeAdminRefreshFamily(refresh.FamilyID); err != nil {
+			panic(err)
+		}
+		panic(httperr.ErrUnauthenticated)
+	}
+
+	admin, found, err := models.GetAdminByID(c.App.DB, refresh.AdminID)
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrUnauthenticated)
+	}
+
+	resp, err := c.issueTokens(admin, refresh.FamilyID, r)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, 200, resp); err != nil {
+		panic(err)
+	}
+}
+
+// issueTokens issues an access token paired with a refresh token, in a new
+// family when familyID is empty. Both are stored before they are handed out
+// so that they can be revoked right away.
+func (c *AuthAdminController) issueTokens(admin *models.Admin, familyID string, r *http.Request) (responses.AuthToken, error) {
+	accessTTL := defaultAdminAccessTTL
+	if ttl := c.App.Config.AdminToken.AccessTTLSeconds; ttl > 0 {
+		accessTTL = time.Duration(ttl) * time.Second
+	}
+	refreshTTL := defaultAdminRefreshTTL
+	if ttl := c.App.Config.AdminToken.RefreshTTLSeconds; ttl > 0 {
+		refreshTTL = time.Duration(ttl) * time.Second
+	}
+
+	token, err := admin.IssueAccessToken(time.Now().Add(accessTTL))
+	if err != nil {
+		return responses.AuthToken{}, fmt.Errorf("[AuthAdminController.issueTokens][IssueAccessToken]%w", err)
+	}
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, c.App.SigningKey))
+	if err != nil {
+		return responses.AuthToken{}, fmt.Errorf("[AuthAdminController.issueTokens][Sign]%w", err)
+	}
+
+	accessToken := models.AdminAccessToken{
+		Model:              models.Model{ID: token.JwtID()},
+		AdminID:            admin.ID,
+		ExpiredAt:          null.TimeFrom(token.Expiration()),
+		RequestFingerprint: controllers.GetRequestFingerprint(r),
+	}
+	if err := accessToken.Insert(c.App.DB); err != nil {
+		return responses.AuthToken{}, fmt.Errorf("[AuthAdminController.issueTokens]%w", err)
+	}
+	refresh, plain := models.NewAdminRefreshToken(admin.ID, familyID, accessToken.ID, refreshTTL)
+	if err := refresh.Insert(c.App.DB); err != nil {
+		return responses.AuthToken{}, fmt.Errorf("[AuthAdminController.issueTokens]%w", err)
+	}
+
+	return responses.AuthToken{
+		AccessToken:  string(signed),
+		TokenType:    "Bearer",
+		ExpiresIn:    int(accessTTL.Seconds()),
+		RefreshToken: plain,
+	}, nil
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/ratelimiter"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+	"gopkg.in/guregu/null.v4"
+)
+
+const (
+	defaultSystemTokenTTL       = time.Hour
+	defaultMaxFailedAttempts    = 5
+	defaultFailureWindowSeconds = 900
+)
+
+type AuthSystemController struct {
+	controllers.Controller
+}
+
+func NewAuthSystemController(app *app.Registry) *AuthSystemController {
+	return &AuthSystemController{controllers.Controller{App: app}}
+}
+
+// Token implements the client_credentials grant of RFC 6749 section 4.4.
+// The client authenticates with HTTP Basic or with client_id and
+// client_secret in the form, and gets a short-lived token carrying the
+// requested scopes, all of its granted scopes when none is requested.
+func (c *AuthSystemController) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		c.oauthError(w, http.StatusBadRequest, "invalid_request", "request body is not a form")
+		return
+	}
+	if grantType := r.PostForm.Get("grant_type"); grantType == "" {
+		c.oauthError(w, http.StatusBadRequest, "invalid_request", "grant_type is required")
+		return
+	} else if grantType != "client_credentials" {
+		c.oauthError(w, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+		return
+	}
+
+	// Basic credentials are form-encoded first, system IDs hold a colon
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		c.oauthError(w, http.StatusBadRequest, "invalid_request", "client credentials are required")
+		return
+	}
+
+	// Every attempt counts as failed until the secret checks out, so that a
+	// burst of concurrent guesses cannot all get past the limit
+	limiters := c.limiters(clientID, c.clientIP(r))
+	attempts := make([]string, len(limiters))
+	for i := range limiters {
+		key, err := limiters[i].Reserve(c.maxFailedAttempts())
+		attempts[i] = key
+		if errors.Is(err, ratelimiter.ErrRateLimited) {
+			c.releaseAttempts(limiters, attempts)
+			w.Header().Set("Retry-After", strconv.Itoa(int(limiters[i].Expiration.Seconds())))
+			c.oauthError(w, http.StatusTooManyRequests, "slow_down", "too many failed attempts, retry later")
+			return
+		} else if err != nil {
+			c.releaseAttempts(limiters, attempts)
+			panic(err)
+		}
+	}
+
+	system, found, err := models.GetSystemByID(c.App.DB, clientID)
+	if err != nil {
+		c.releaseAttempts(limiters, attempts)
+		panic(err)
+	}
+	if !found || !system.VerifySecretKey(secret) {
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="systems"`)
+		}
+		c.oauthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	c.releaseAttempts(limiters, attempts)
+
+	granted, err := models.GetSystemScopes(c.App.DB, system.ID)
+	if err != nil {
+		panic(err)
+	} else if len(granted) == 0 {
+		c.oauthError(w, http.StatusBadRequest, "invalid_scope", "no scope is granted to the client")
+		return
+	}
+	scopes := granted
+	if scope := strings.TrimSpace(r.PostForm.Get("scope")); scope != "" {
+		scopes = strings.Fields(scope)
+		for _, s := range scopes {
+			if !slices.Contains(granted, s) {
+				c.oauthError(w, http.StatusBadRequest, "invalid_scope", "scope "+s+" is not granted to the client")
+				return
+			}
+		}
+	}
+
+	ttl := c.tokenTTL()
+	token, err := system.IssueScopedAccessToken(time.Now().Add(ttl), scopes)
+	if err != nil {
+		panic(err)
+	}
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, c.App.SigningKey))
+	if err != nil {
+		panic(err)
+	}
+
+	accessToken := models.SystemAccessToken{
+		Model:     models.Model{ID: token.JwtID()},
+		SystemID:  system.ID,
+		ExpiredAt: null.TimeFrom(token.Expiration()),
+	}
+	if err := accessToken.Insert(c.App.DB); err != nil {
+		panic(err)
+	}
+
+	// Only the failures of the client are forgotten, the address may still
+	// be guessing other clients
+	if err := limiters[0].Clear(); err != nil {
+		c.App.Log.Errorf("[AuthSystemController.Token] %v", err)
+	}
+
+	err = responses.OAuth(w, http.StatusOK, responses.OAuthToken{
+		AccessToken: string(signed),
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *AuthSystemController) oauthError(w http.ResponseWriter, status int, code string, description string) {
+	if err := responses.OAuth(w, status, responses.OAuthError{Error: code, Description: description}); err != nil {
+		panic(err)
+	}
+}
+
+// limiters count the failed attempts of a client and of an address, the
+// client one first.
+func (c *AuthSystemController) limiters(clientID string, ip string) []ratelimiter.RateLimiter {
+	window := time.Duration(c.App.Config.SystemToken.FailureWindowSeconds) * time.Second
+	if window <= 0 {
+		window = defaultFailureWindowSeconds * time.Second
+	}
+	return []ratelimiter.RateLimiter{
+		ratelimiter.New(c.App.Cache, "system_token", []string{"client", clientID}, window),
+		ratelimiter.New(c.App.Cache, "system_token", []string{"ip", ip}, window),
+	}
+}
+
+func (c *AuthSystemController) maxFailedAttempts() int {
+	if max := c.App.Config.SystemToken.MaxFailedAttempts; max > 0 {
+		return max
+	}
+	return defaultMaxFailedAttempts
+}
+
+func (c *AuthSystemController) tokenTTL() time.Duration {
+	if ttl := c.App.Config.SystemToken.TTLSeconds; ttl > 0 {
+		return time.Duration(ttl) * time.Second
+	}
+	return defaultSystemTokenTTL
+}
+
+// releaseAttempts takes back the attempts reserved so far, they were not
+// failed authentications.
+func (c *AuthSystemController) releaseAttempts(limiters []ratelimiter.RateLimiter, attempts []string) {
+	for i, key := range attempts {
+		if key == "" {
+			continue
+		}
+		if err := limiters[i].Release(key); err != nil {
+			c.App.Log.Errorf("[AuthSystemController.releaseAttempts] %v", err)
+		}
+	}
+}
+
+// clientIP is the address of the client. X-Real-IP is only believed when
+// the request comes from one of the trusted proxies, anyone else could set
+// it to dodge the limit of their address.
+func (c *AuthSystemController) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrustedProxy(c.App.Config.TrustedProxies, host) {
+		return host
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+// isTrustedProxy reports whether addr is one of proxies, given as addresses
+// or CIDR ranges.
+func isTrustedProxy(proxies []string, addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range proxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if proxyIP := net.ParseIP(proxy); proxyIP != nil && proxyIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
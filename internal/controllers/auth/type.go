@@ -77,9 +77,23 @@ func (r LoginByCodeRequest) Validate(ctx *reqdata.Context) error {
 			if exist, err := ctx.App.Cache.Has(key); err != nil {
 				return err
 			} else if !exist {
-				return validation.Errors{"code": fmt.Errorf("invalid code")}
+				return validation.Errors{"code": validation.NewError("invalid_code", "invalid code")}
 			}
 			return nil
 		})),
 	)
 }
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (r *RefreshTokenRequest) Authorized(_ *reqdata.Context) bool {
+	return true
+}
+
+func (r *RefreshTokenRequest) Validate(_ *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.RefreshToken, validation.Required),
+	)
+}
@@ -58,6 +58,7 @@ func (c *Controller) RequestContext(r *http.Request) *reqdata.Context {
 		App:         c.App,
 		Auth:        auth,
 		HTTPContext: r.Context(),
+		Language:    middlewares.RequestLanguage(r),
 	}
 }
 
@@ -0,0 +1,149 @@
+package campaign
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/jobs"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+type CampaignController struct {
+	controllers.Controller
+}
+
+func NewCampaignController(app *app.Registry) *CampaignController {
+	return &CampaignController{controllers.Controller{App: app}}
+}
+
+func (c *CampaignController) GetCampaigns(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := models.GetCampaigns(c.App.DB)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, campaigns); err != nil {
+		panic(err)
+	}
+}
+
+func (c *CampaignController) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign := c.campaignFromURL(r)
+	if err := responses.JSON(w, http.StatusOK, campaign); err != nil {
+		panic(err)
+	}
+}
+
+func (c *CampaignController) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	var req CampaignRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	campaign := req.Campaign()
+	c.checkPriceJumps(campaign)
+	tx := c.App.DB.MustBegin()
+	if err := campaign.Insert(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.syncCampaignPrices()
+	c.respondWithCampaign(w, http.StatusCreated, campaign.ID)
+}
+
+func (c *CampaignController) UpdateCampaign(w http.ResponseWriter, r *http.Request) {
+	existing := c.campaignFromURL(r)
+
+	var req CampaignRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	campaign := req.Campaign()
+	campaign.ID = existing.ID
+	c.checkPriceJumps(campaign)
+	tx := c.App.DB.MustBegin()
+	if err := campaign.Update(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.syncCampaignPrices()
+	c.respondWithCampaign(w, http.StatusOK, campaign.ID)
+}
+
+func (c *CampaignController) DeleteCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign := c.campaignFromURL(r)
+
+	tx := c.App.DB.MustBegin()
+	if err := campaign.Delete(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.syncCampaignPrices()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkPriceJumps refuses amount and fixed price campaigns moving a phone
+// price past the approval threshold, which a single admin cannot do on the
+// phone itself either.
+func (c *CampaignController) checkPriceJumps(campaign models.Campaign) {
+	phones, err := models.GetCatalogPhones(c.App.DB)
+	if err != nil {
+		panic(err)
+	}
+	jumps := campaign.PriceJumps(phones, c.App.Config.PriceApproval.ThresholdPercent)
+	if len(jumps) > 0 {
+		panic(httperr.NewErrUnprocessableEntity("campaign_exceeds_price_threshold", "the campaign moves phone prices past the approval threshold", jumps))
+	}
+}
+
+func (c *CampaignController) respondWithCampaign(w http.ResponseWriter, status int, id int) {
+	campaign, _, err := models.GetCampaign(c.App.DB, id)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, status, campaign); err != nil {
+		panic(err)
+	}
+}
+
+// syncCampaignPrices applies a campaign change right away instead of waiting
+// for the next scheduler tick.
+func (c *CampaignController) syncCampaignPrices() {
+	go func() {
+		if err := jobs.SyncCampaignPrices(c.App)(); err != nil {
+			c.App.Log.Errorf("[CampaignController.syncCampaignPrices] %v", err)
+		}
+	}()
+}
+
+func (c *CampaignController) campaignFromURL(r *http.Request) *models.Campaign {
+	id, err := strconv.Atoi(chi.URLParam(r, "CampaignID"))
+	if err != nil {
+		panic(httperr.ErrNotFound)
+	}
+
+	campaign, found, err := models.GetCampaign(c.App.DB, id)
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrNotFound)
+	}
+	return campaign
+}
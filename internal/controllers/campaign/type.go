@@ -0,0 +1,83 @@
+package campaign
+
+import (
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+type CampaignRequest struct {
+	Name      string                  `json:"name"`
+	RuleType  string                  `json:"rule_type"`
+	RuleValue money.Amount            `json:"rule_value"`
+	Priority  int                     `json:"priority"`
+	StartsAt  time.Time               `json:"starts_at"`
+	EndsAt    time.Time               `json:"ends_at"`
+	Targets   []CampaignTargetRequest `json:"targets"`
+}
+
+type CampaignTargetRequest struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+func (r *CampaignRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionCampaignManage)
+}
+
+func (r *CampaignRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 255)),
+		validation.Field(&r.RuleType, validation.Required, validation.In(models.CampaignRulePercentOff, models.CampaignRuleAmountOff, models.CampaignRuleFixedPrice)),
+		validation.Field(&r.RuleValue,
+			validation.By(money.Positive),
+			validation.When(r.RuleType == models.CampaignRulePercentOff, validation.Max(money.FromInt(100))),
+		),
+		validation.Field(&r.StartsAt, validation.Required),
+		validation.Field(&r.EndsAt, validation.Required, validation.Min(r.StartsAt).Exclusive()),
+		validation.Field(&r.Targets, validation.Required, validation.Each(validation.By(func(value interface{}) error {
+			target := value.(CampaignTargetRequest)
+			return target.validate(ctx)
+		}))),
+	)
+}
+
+func (t CampaignTargetRequest) validate(ctx *reqdata.Context) error {
+	var err error
+	switch t.Type {
+	case models.CampaignTargetPhone:
+		_, err = models.GetPhone(ctx.App.DB, t.ID)
+	case models.CampaignTargetBrand:
+		_, err = models.GetBrand(ctx.App.DB, t.ID)
+	case models.CampaignTargetTag:
+		_, err = models.GetTag(ctx.App.DB, t.ID)
+	default:
+		return validation.NewError("invalid_target_type", "target type must be one of {{.types}}").
+			SetParams(map[string]any{"types": strings.Join([]string{models.CampaignTargetPhone, models.CampaignTargetBrand, models.CampaignTargetTag}, ", ")})
+	}
+	if err != nil {
+		return validation.NewError("invalid_target_id", "{{.type}} {{.id}} does not exist").SetParams(map[string]any{"type": t.Type, "id": t.ID})
+	}
+	return nil
+}
+
+func (r *CampaignRequest) Campaign() models.Campaign {
+	campaign := models.Campaign{
+		Name:      r.Name,
+		RuleType:  r.RuleType,
+		RuleValue: r.RuleValue,
+		Priority:  r.Priority,
+		StartsAt:  r.StartsAt,
+		EndsAt:    r.EndsAt,
+		Targets:   make([]models.CampaignTarget, 0, len(r.Targets)),
+	}
+	for _, t := range r.Targets {
+		campaign.Targets = append(campaign.Targets, models.CampaignTarget{TargetType: t.Type, TargetID: t.ID})
+	}
+	return campaign
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/permission"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
 	"gopkg.in/guregu/null.v4"
@@ -45,12 +47,7 @@ type LoginContext struct {
 	RequestFingerprint RequestFingerprint `json:"request_fingerprint"`
 }
 
-type RequestFingerprint struct {
-	UserAgent    string `json:"user_agent"`
-	Forwarded    string `json:"forward"`
-	ForwardedFor string `json:"forwarded_for"`
-	RealIP       string `json:"real_ip"`
-}
+type RequestFingerprint = models.RequestFingerprint
 
 func GetRequestFingerprint(r *http.Request) RequestFingerprint {
 	return RequestFingerprint{
@@ -101,8 +98,9 @@ func GenerateAccessToken(app *app.Registry, user models.JWTAuthenticatable, d ti
 				Model: models.Model{
 					ID: token.JwtID(),
 				},
-				AdminID:   u.ID,
-				ExpiredAt: expiredAt,
+				AdminID:            u.ID,
+				ExpiredAt:          expiredAt,
+				RequestFingerprint: authCtx.RequestFingerprint,
 			}
 			err := t.Insert(tx)
 			if err != nil {
@@ -185,3 +183,28 @@ type PaginationDetail struct {
 	Asc            bool   `json:"asc"`
 	HasNext        bool   `json:"has_next"`
 }
+
+// IsAdminAuthorized reports whether the request comes from an admin whose
+// role holds the permission.
+func IsAdminAuthorized(ctx *reqdata.Context, identifier string) bool {
+	admin, err := GetAdminFromAuth(ctx.Auth)
+	if err != nil {
+		return false
+	}
+	allowed, err := permission.AdminHas(ctx.App.Cache, ctx.App.DB, admin, identifier)
+	if err != nil {
+		ctx.App.Log.Errorf("[IsAdminAuthorized] %v", err)
+		return false
+	}
+	return allowed
+}
+
+// HasPermission reports whether the request comes from an admin whose role
+// holds the permission, or from a system whose token holds a scope standing
+// for it.
+func HasPermission(ctx *reqdata.Context, identifier string) bool {
+	if ctx.Auth != nil && ctx.Auth.AccountType() == models.AccountTypeSystem {
+		return models.ScopesGrantPermission(middlewares.TokenScopes(ctx.Auth.Token()), identifier)
+	}
+	return IsAdminAuthorized(ctx, identifier)
+}
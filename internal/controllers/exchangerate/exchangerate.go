@@ -0,0 +1,81 @@
+package exchangerate
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+type ExchangeRateController struct {
+	controllers.Controller
+}
+
+func NewExchangeRateController(app *app.Registry) *ExchangeRateController {
+	return &ExchangeRateController{controllers.Controller{App: app}}
+}
+
+func (c *ExchangeRateController) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := models.GetExchangeRates(c.App.DB)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, rates); err != nil {
+		panic(err)
+	}
+}
+
+// SaveExchangeRate creates or replaces the rate of the currency in the URL.
+func (c *ExchangeRateController) SaveExchangeRate(w http.ResponseWriter, r *http.Request) {
+	req := ExchangeRateRequest{Currency: currencyFromURL(r)}
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	rate := req.ExchangeRate()
+	tx := c.App.DB.MustBegin()
+	if err := rate.Save(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	saved, _, err := models.GetExchangeRate(c.App.DB, rate.Currency)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, saved); err != nil {
+		panic(err)
+	}
+}
+
+func (c *ExchangeRateController) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	rate, found, err := models.GetExchangeRate(c.App.DB, currencyFromURL(r))
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrNotFound)
+	}
+
+	tx := c.App.DB.MustBegin()
+	if err := rate.Delete(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func currencyFromURL(r *http.Request) string {
+	return strings.ToUpper(chi.URLParam(r, "Currency"))
+}
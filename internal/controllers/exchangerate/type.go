@@ -0,0 +1,46 @@
+package exchangerate
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+type ExchangeRateRequest struct {
+	Currency     string       `json:"-"`
+	Rate         float64      `json:"rate"`
+	RoundingStep money.Amount `json:"rounding_step"`
+}
+
+func (r *ExchangeRateRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionExchangeRateManage)
+}
+
+func (r *ExchangeRateRequest) Validate(_ *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Currency, validation.Required, validation.By(func(value interface{}) error {
+			currency := value.(string)
+			if !models.IsCurrencyCode(currency) || currency == models.CanonicalCurrency {
+				return validation.NewError("invalid_currency_code", "currency must be an ISO 4217 code other than {{.currency}}").
+					SetParams(map[string]any{"currency": models.CanonicalCurrency})
+			}
+			return nil
+		})),
+		validation.Field(&r.Rate, validation.Required, validation.Min(0.0).Exclusive()),
+		validation.Field(&r.RoundingStep, validation.By(money.NotNegative)),
+	)
+}
+
+func (r *ExchangeRateRequest) ExchangeRate() models.ExchangeRate {
+	step := r.RoundingStep
+	if step == 0 {
+		step = money.FromInt(1)
+	}
+	return models.ExchangeRate{
+		Currency:     r.Currency,
+		Rate:         r.Rate,
+		RoundingStep: step,
+	}
+}
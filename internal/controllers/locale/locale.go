@@ -0,0 +1,126 @@
+package locale
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/jobs"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+type LocaleController struct {
+	controllers.Controller
+}
+
+func NewLocaleController(app *app.Registry) *LocaleController {
+	return &LocaleController{controllers.Controller{App: app}}
+}
+
+// GetLocales summarizes the catalog of every supported language.
+func (c *LocaleController) GetLocales(w http.ResponseWriter, r *http.Request) {
+	overrides, err := models.GetLocaleMessageOverrides(c.App.DB)
+	if err != nil {
+		panic(err)
+	}
+
+	locales := []LocaleSummary{}
+	for _, lang := range c.App.Localizer.Languages() {
+		locales = append(locales, LocaleSummary{
+			Locale:     lang,
+			Messages:   len(c.App.Localizer.Messages(lang)),
+			Missing:    len(c.App.Localizer.Missing(lang)),
+			Overridden: len(overrides[lang]),
+		})
+	}
+	if err := responses.JSON(w, http.StatusOK, locales); err != nil {
+		panic(err)
+	}
+}
+
+// GetLocaleMessages lists every message of a locale by message ID.
+func (c *LocaleController) GetLocaleMessages(w http.ResponseWriter, r *http.Request) {
+	entries := c.localeEntries(c.localeFromURL(r))
+	if err := responses.JSON(w, http.StatusOK, entries); err != nil {
+		panic(err)
+	}
+}
+
+// GetMissingMessages lists the DefaultLanguage messages which have no
+// translation in the locale yet.
+func (c *LocaleController) GetMissingMessages(w http.ResponseWriter, r *http.Request) {
+	lang := c.localeFromURL(r)
+
+	defaults := c.App.Localizer.Messages(app.DefaultLanguage)
+	entries := []LocaleEntry{}
+	for _, id := range c.App.Localizer.Missing(lang) {
+		entries = append(entries, LocaleEntry{MessageID: id, Default: defaults[id]})
+	}
+	if err := responses.JSON(w, http.StatusOK, entries); err != nil {
+		panic(err)
+	}
+}
+
+// UpdateLocaleMessages stores the messages of a locale and rebuilds the
+// localizer, other instances pick them up on their next reload.
+func (c *LocaleController) UpdateLocaleMessages(w http.ResponseWriter, r *http.Request) {
+	req := UpdateLocaleMessagesRequest{Locale: chi.URLParam(r, "Locale")}
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	tx := c.App.DB.MustBegin()
+	for id, message := range req.Messages {
+		var err error
+		if message == "" {
+			err = models.DeleteLocaleMessages(tx, req.Locale, id)
+		} else {
+			m := models.LocaleMessage{Locale: req.Locale, MessageID: id, Message: message}
+			err = m.Save(tx)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			panic(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+	if err := jobs.ReloadLocalizer(c.App); err != nil {
+		panic(err)
+	}
+
+	if err := responses.JSON(w, http.StatusOK, c.localeEntries(req.Locale)); err != nil {
+		panic(err)
+	}
+}
+
+func (c *LocaleController) localeEntries(lang string) []LocaleEntry {
+	overrides, err := models.GetLocaleMessageOverrides(c.App.DB)
+	if err != nil {
+		panic(err)
+	}
+
+	defaults := c.App.Localizer.Messages(app.DefaultLanguage)
+	entries := []LocaleEntry{}
+	for id, message := range c.App.Localizer.Messages(lang) {
+		_, overridden := overrides[lang][id]
+		entries = append(entries, LocaleEntry{MessageID: id, Message: message, Default: defaults[id], Overridden: overridden})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].MessageID < entries[j].MessageID
+	})
+	return entries
+}
+
+func (c *LocaleController) localeFromURL(r *http.Request) string {
+	lang := chi.URLParam(r, "Locale")
+	if !c.App.Localizer.Supports(lang) {
+		panic(httperr.ErrNotFound)
+	}
+	return lang
+}
@@ -0,0 +1,69 @@
+package locale
+
+import (
+	"text/template"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+)
+
+// UpdateLocaleMessagesRequest overrides messages of a locale. A message set
+// to an empty string loses its override and the locale file applies again.
+type UpdateLocaleMessagesRequest struct {
+	Locale   string            `json:"-"`
+	Messages map[string]string `json:"messages"`
+}
+
+func (r *UpdateLocaleMessagesRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionLocaleManage)
+}
+
+func (r *UpdateLocaleMessagesRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Locale, validation.By(func(value interface{}) error {
+			if !ctx.App.Localizer.Supports(value.(string)) {
+				return validation.NewError("invalid_locale", "locale is not supported")
+			}
+			return nil
+		})),
+		validation.Field(&r.Messages, validation.Required, validation.By(func(value interface{}) error {
+			// An override of a message the code never asks for would never show
+			known := ctx.App.Localizer.Messages(app.DefaultLanguage)
+			for id, message := range value.(map[string]string) {
+				if id == "" || len(id) > 191 {
+					return validation.NewError("invalid_message_id", "message IDs must have 1 to 191 characters")
+				}
+				if _, ok := known[id]; !ok {
+					return validation.NewError("invalid_unknown_message_id", "{{.id}} is not a message of {{.language}}").
+						SetParams(map[string]any{"id": id, "language": app.DefaultLanguage})
+				}
+				if _, err := template.New(id).Parse(message); err != nil {
+					return validation.NewError("invalid_message", "{{.id}} is not a valid message template").
+						SetParams(map[string]any{"id": id})
+				}
+			}
+			return nil
+		})),
+	)
+}
+
+// LocaleSummary counts the messages of a locale.
+type LocaleSummary struct {
+	Locale     string `json:"locale"`
+	Messages   int    `json:"messages"`
+	Missing    int    `json:"missing"`
+	Overridden int    `json:"overridden"`
+}
+
+// LocaleEntry is a message of a locale next to its DefaultLanguage message.
+// Overridden tells whether it comes from the database instead of the locale
+// file.
+type LocaleEntry struct {
+	MessageID  string `json:"message_id"`
+	Message    string `json:"message"`
+	Default    string `json:"default"`
+	Overridden bool   `json:"overridden"`
+}
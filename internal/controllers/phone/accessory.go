@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"net/http"
+
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+// UpdateCompatibleAccessories replaces the accessories that fit a phone.
+func (c *PhoneController) UpdateCompatibleAccessories(w http.ResponseWriter, r *http.Request) {
+	phone := c.phoneFromURL(r)
+	if phone.IsAccessory() {
+		panic(httperr.NewErrUnprocessableEntity("phone_is_accessory", "accessories cannot have compatible accessories", nil))
+	}
+
+	req := UpdateCompatibleAccessoriesRequest{PhoneID: phone.ID}
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	tx := c.App.DB.MustBegin()
+	if err := models.ReplaceCompatibleAccessories(tx, phone.ID, req.AccessoryIDs); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.respondWithCompatibility(w, phone)
+}
+
+// UpdateCompatibleDevices replaces the devices an accessory fits.
+func (c *PhoneController) UpdateCompatibleDevices(w http.ResponseWriter, r *http.Request) {
+	accessory := c.phoneFromURL(r)
+	if !accessory.IsAccessory() {
+		panic(httperr.NewErrUnprocessableEntity("phone_is_not_accessory", "only accessories can have compatible devices", nil))
+	}
+
+	req := UpdateCompatibleDevicesRequest{AccessoryID: accessory.ID}
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	tx := c.App.DB.MustBegin()
+	if err := models.ReplaceCompatibleDevices(tx, accessory.ID, req.PhoneIDs); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.respondWithCompatibility(w, accessory)
+}
+
+// CopyCompatibility links every accessory of another phone to this phone, for
+// new models that reuse the cases and chargers of an older one.
+func (c *PhoneController) CopyCompatibility(w http.ResponseWriter, r *http.Request) {
+	phone := c.phoneFromURL(r)
+	if phone.IsAccessory() {
+		panic(httperr.NewErrUnprocessableEntity("phone_is_accessory", "accessories cannot have compatible accessories", nil))
+	}
+
+	req := CopyCompatibilityRequest{PhoneID: phone.ID}
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	tx := c.App.DB.MustBegin()
+	if _, err := models.CopyAccessoryCompatibility(tx, req.FromPhoneID, phone.ID); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.respondWithCompatibility(w, phone)
+}
+
+func (c *PhoneController) respondWithCompatibility(w http.ResponseWriter, phone models.Phone) {
+	if err := phone.LoadCompatibility(c.App.DB); err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, phone); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"net/http"
+
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+type bulkEditResponse struct {
+	DryRun  bool                `json:"dry_run"`
+	Results []models.BulkResult `json:"results"`
+}
+
+// BulkEditPhones applies the same operations to every selected phone in one
+// transaction. A dry run goes through the same path and rolls back, so the
+// preview shows exactly what would be written, price rules included. When
+// any phone fails nothing is saved.
+func (c *PhoneController) BulkEditPhones(w http.ResponseWriter, r *http.Request) {
+	var form BulkEditRequest
+	if err := c.Validate(&form, r); err != nil {
+		panic(err)
+	}
+
+	tx := c.App.DB.MustBegin()
+	ids, err := models.GetBulkPhoneIDs(tx, form.Selector)
+	if err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+
+	rules, err := models.GetEnabledPricingRules(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+
+	results, err := models.RunBulkEdit(tx, ids, form.Operations, models.BulkOptions{
+		ActorID:            c.RequestContext(r).Auth.UserID(),
+		ConfirmPriceChange: form.ConfirmPriceChange,
+		ApprovalThreshold:  c.App.Config.PriceApproval.ThresholdPercent,
+		PriceRules:         rules,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		for i := range results {
+			results[i] = results[i].WithoutSavedRecords()
+		}
+		c.App.Log.Errorf("[PhoneController.BulkEditPhones] %v", err)
+		panic(httperr.NewErrUnprocessableEntity("bulk_edit_failed", "no phone was updated, see the failed result", bulkEditResponse{form.DryRun, results}))
+	}
+
+	if form.DryRun {
+		_ = tx.Rollback()
+		for i := range results {
+			results[i] = results[i].WithoutSavedRecords()
+		}
+		if err := responses.JSON(w, http.StatusOK, bulkEditResponse{true, results}); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.refreshRecommendations()
+	for _, result := range results {
+		if result.PriceChangeRequest != nil {
+			c.notifyPriceApprovers(result.PriceChangeRequest)
+		}
+	}
+
+	if err := responses.JSON(w, http.StatusOK, bulkEditResponse{false, results}); err != nil {
+		panic(err)
+	}
+}
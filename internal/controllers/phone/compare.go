@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+// ComparePhones returns a side-by-side comparison of up to
+// models.MaxComparedPhones phones given as a comma separated "ids" query.
+func (c *PhoneController) ComparePhones(w http.ResponseWriter, r *http.Request) {
+	ids, err := parseCompareIDs(r.URL.Query().Get("ids"))
+	if err != nil {
+		panic(validation.Errors{"ids": err})
+	}
+	rate, err := c.displayCurrency(r)
+	if err != nil {
+		panic(err)
+	}
+
+	phones := make([]models.Phone, 0, len(ids))
+	installments := make(map[int]*models.Installment, len(ids))
+	for _, id := range ids {
+		phone, err := models.GetPhone(c.App.DB, id)
+		if err != nil {
+			panic(err)
+		}
+		phones = append(phones, phone)
+
+		installment, exist, err := models.GetInstallmentByPhoneID(c.App.DB, id)
+		if err != nil {
+			panic(err)
+		}
+		if exist {
+			installments[id] = installment
+		}
+	}
+
+	if err := models.TranslatePhones(c.App.DB, phones, c.catalogLanguage(r)); err != nil {
+		panic(err)
+	}
+	setDisplayPrices(rate, phones)
+
+	if err := responses.JSON(w, http.StatusOK, models.ComparePhones(phones, installments)); err != nil {
+		panic(err)
+	}
+}
+
+func parseCompareIDs(raw string) ([]int, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, validation.NewError("validation_required", "ids is required")
+	}
+
+	var ids []int
+	seen := map[int]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			return nil, validation.NewError("invalid_id", "{{.id}} is not a valid phone id").SetParams(map[string]any{"id": part})
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if len(ids) < 2 || len(ids) > models.MaxComparedPhones {
+		return nil, validation.NewError("invalid_compare_ids", "ids must contain between {{.min}} and {{.max}} distinct phones").
+			SetParams(map[string]any{"min": 2, "max": models.MaxComparedPhones})
+	}
+	return ids, nil
+}
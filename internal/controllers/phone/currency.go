@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+// displayCurrency returns the exchange rate asked for with the "currency"
+// query. It is nil when no currency, or the canonical one, is asked for.
+func (c *PhoneController) displayCurrency(r *http.Request) (*models.ExchangeRate, error) {
+	currency := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("currency")))
+	if currency == "" || currency == models.CanonicalCurrency {
+		return nil, nil
+	}
+
+	rate, found, err := models.GetExchangeRate(c.App.DB, currency)
+	if err != nil {
+		return nil, err
+	} else if !found {
+		return nil, validation.Errors{"currency": validation.NewError("invalid_currency", "no exchange rate for {{.currency}}").SetParams(map[string]any{"currency": currency})}
+	}
+	return rate, nil
+}
+
+// setDisplayPrices adds the converted prices to phones when a rate is given.
+func setDisplayPrices(rate *models.ExchangeRate, phones []models.Phone) {
+	if rate == nil {
+		return
+	}
+	for i := range phones {
+		phones[i].DisplayPrices = rate.DisplayPrices(phones[i])
+	}
+}
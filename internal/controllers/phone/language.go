@@ -0,0 +1,11 @@
+package controller
+
+import (
+	"net/http"
+)
+
+// catalogLanguage negotiates the language of catalog content, the "lang"
+// query takes precedence over the Accept-Language header.
+func (c *PhoneController) catalogLanguage(r *http.Request) string {
+	return c.App.Localizer.Negotiate(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+}
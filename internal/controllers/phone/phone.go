@@ -1,15 +1,18 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
 	controllers "github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/pricing"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/render"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
 type PhoneController struct {
@@ -39,130 +42,208 @@ func (c *PhoneController) GetPhones(w http.ResponseWriter, r *http.Request) {
 		offset = 0 // Default offset
 	}
 
+	rate, err := c.displayCurrency(r)
+	if err != nil {
+		panic(err)
+	}
+
 	phones, err := models.GetPhones(c.App.DB, limit, offset, sortBy, order, filterBy, filterValue)
+	if errors.Is(err, models.ErrInvalidPhoneFilter) {
+		panic(validation.Errors{"filterBy": validation.NewError("invalid_filter_by", "unknown filter_by {{.filter_by}}").
+			SetParams(map[string]any{"filter_by": filterBy})})
+	} else if errors.Is(err, models.ErrInvalidPhoneSort) {
+		panic(validation.Errors{"sortBy": validation.NewError("invalid_sort_by", "unknown sortBy {{.sort_by}}").
+			SetParams(map[string]any{"sort_by": sortBy})})
+	} else if errors.Is(err, models.ErrInvalidPhoneOrder) {
+		panic(validation.Errors{"order": validation.NewError("invalid_order", "order must be asc or desc")})
+	} else if err != nil {
+		panic(err)
+	}
+	err = models.TranslatePhones(c.App.DB, phones, c.catalogLanguage(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		panic(err)
 	}
+	setDisplayPrices(rate, phones)
 
-	render.JSON(w, r, phones)
+	if err := responses.JSON(w, http.StatusOK, phones); err != nil {
+		panic(err)
+	}
 }
 
 // GetPhone retrieves a single phone record by ID
 func (c *PhoneController) GetPhone(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "PhoneID"))
+	rate, err := c.displayCurrency(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		panic(err)
 	}
 
-	phone, err := models.GetPhone(c.App.DB, id)
+	phone := c.phoneFromURL(r)
+	err = phone.LoadCompatibility(c.App.DB)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		panic(err)
 	}
+	phones := []models.Phone{phone}
+	err = models.TranslatePhones(c.App.DB, phones, c.catalogLanguage(r))
+	if err != nil {
+		panic(err)
+	}
+	phone = phones[0]
+	if rate != nil {
+		phone.DisplayPrices = rate.DisplayPrices(phone)
+	}
+
+	if err := responses.JSON(w, http.StatusOK, phone); err != nil {
+		panic(err)
+	}
+}
+
+// GetPriceHistory lists the price changes of a phone, latest first.
+func (c *PhoneController) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	phone := c.phoneFromURL(r)
 
-	render.JSON(w, r, phone)
+	history, err := models.GetPriceHistory(c.App.DB, phone.ID)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, history); err != nil {
+		panic(err)
+	}
 }
 
 // CreatePhone creates a new phone record and inserts installment values
 func (c *PhoneController) CreatePhone(w http.ResponseWriter, r *http.Request) {
-	var phone models.Phone
-	if err := render.Bind(r, &phone); err != nil {
-		http.Error(w, "Invalid request payload: "+err.Error(), http.StatusBadRequest)
-		return
+	var req CreatePhoneRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
 	}
 
+	phone := req.Phone()
 	tx := c.App.DB.MustBegin()
-	err := phone.Insert(tx)
+	rules, err := models.GetEnabledPricingRules(tx)
 	if err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to create phone record: "+err.Error(), http.StatusInternalServerError)
-		return
+		_ = tx.Rollback()
+		panic(err)
 	}
-
-	err = tx.Commit()
+	err = phone.Insert(tx, rules)
 	if err != nil {
-		http.Error(w, "Failed to commit transaction: "+err.Error(), http.StatusInternalServerError)
-		return
+		_ = tx.Rollback()
+		panic(priceError(err, phone))
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	render.JSON(w, r, phone)
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.refreshRecommendations()
+
+	if err := responses.JSON(w, http.StatusCreated, phone); err != nil {
+		panic(err)
+	}
 }
 
 // UpdatePhone updates an existing phone record by ID and records price changes
 func (c *PhoneController) UpdatePhone(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "PhoneID"))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	req := UpdatePhoneRequest{PhoneID: phoneIDFromURL(r)}
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
 	}
 
-	var phone models.Phone
-	if err := render.Bind(r, &phone); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
-		return
+	phone := req.Phone()
+	tx := c.App.DB.MustBegin()
+	oldPrice, err := models.GetPhonePrice(tx, phone.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		panic(err)
 	}
 
-	phone.ID = id
+	// Large price moves wait for a second admin, the rest of the update
+	// applies right away
+	var priceChangeRequest *models.PriceChangeRequest
+	if models.RequiresPriceApproval(oldPrice, phone.Price, c.App.Config.PriceApproval.ThresholdPercent) {
+		priceChangeRequest = &models.PriceChangeRequest{
+			PhoneID:     phone.ID,
+			OldPrice:    oldPrice,
+			NewPrice:    phone.Price,
+			RequestedBy: c.RequestContext(r).Auth.UserID(),
+		}
+		phone.Price = oldPrice
+	}
 
-	tx := c.App.DB.MustBegin()
-	err = phone.Update(tx)
+	rules, err := models.GetEnabledPricingRules(tx)
 	if err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to update phone record", http.StatusInternalServerError)
-		return
+		_ = tx.Rollback()
+		panic(err)
 	}
-
-	installment := models.CalculateInstallments(phone.Price)
-	installment.PhoneID = phone.ID
-	// Delete existing installment records
-	_, err = tx.Exec("DELETE FROM installments WHERE phone_id = ?", phone.ID)
+	err = phone.Update(tx, rules)
 	if err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to delete existing installment records", http.StatusInternalServerError)
-		return
+		_ = tx.Rollback()
+		panic(priceError(err, phone))
 	}
 
-	err = installment.Insert(tx)
+	err = models.ReplaceInstallments(tx, phone.ID, phone.Price)
 	if err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to create installment record", http.StatusInternalServerError)
-		return
+		_ = tx.Rollback()
+		panic(err)
 	}
-	err = tx.Commit()
-	if err != nil {
-		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+
+	if priceChangeRequest != nil {
+		err = priceChangeRequest.Insert(tx)
+		if err != nil {
+			_ = tx.Rollback()
+			panic(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.refreshRecommendations()
+
+	if priceChangeRequest != nil {
+		c.notifyPriceApprovers(priceChangeRequest)
+		err = responses.JSON(w, http.StatusAccepted, map[string]any{
+			"phone":                phone,
+			"price_change_request": priceChangeRequest,
+		})
+		if err != nil {
+			panic(err)
+		}
 		return
 	}
 
-	render.JSON(w, r, phone)
+	if err := responses.JSON(w, http.StatusOK, phone); err != nil {
+		panic(err)
+	}
 }
 
 // DeletePhone deletes a phone record by ID
 func (c *PhoneController) DeletePhone(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "PhoneID"))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	phone := models.Phone{ID: id}
+	phone := models.Phone{ID: phoneIDFromURL(r)}
 
 	tx := c.App.DB.MustBegin()
-	err = phone.Delete(tx)
-	if err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to delete phone record: "+err.Error(), http.StatusInternalServerError)
-		return
+	if err := phone.Delete(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		http.Error(w, "Failed to commit transaction: "+err.Error(), http.StatusInternalServerError)
-		return
+	if err := tx.Commit(); err != nil {
+		panic(err)
 	}
 
+	c.refreshRecommendations()
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// priceError reports the price rules that stopped a price change, the change
+// goes through once resent with confirm_price_change. Other errors are
+// returned as is.
+func priceError(err error, phone models.Phone) error {
+	if !errors.Is(err, pricing.ErrConfirmationRequired) {
+		return err
+	}
+	return httperr.NewErrUnprocessableEntity("price_confirmation_required", "price change requires confirmation", map[string]any{
+		"price_adjustments": phone.PriceAdjustments,
+	})
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/mq"
+)
+
+// notifyPriceApprovers tells the admins allowed to approve price changes that
+// a new request is waiting for them. The request stays pending when the
+// notification fails, approvers still find it in the pending list.
+func (c *PhoneController) notifyPriceApprovers(req *models.PriceChangeRequest) {
+	approvers, err := models.GetAdminIDsWithPermission(c.App.DB, models.PermissionPriceApprove)
+	if err != nil {
+		c.App.Log.Errorf("[PhoneController.notifyPriceApprovers] %v", err)
+		return
+	}
+
+	recipients := make([]string, 0, len(approvers))
+	for _, id := range approvers {
+		if id != req.RequestedBy {
+			recipients = append(recipients, id)
+		}
+	}
+
+	err = mq.PublishMessage(c.App.MessageProducer, mq.PriceChangeRequestedTopic, mq.PriceChangeRequestedMsg{
+		PriceChangeRequestID: req.ID,
+		PhoneID:              req.PhoneID,
+		ApproverIDs:          recipients,
+	})
+	if err != nil {
+		c.App.Log.Errorf("[PhoneController.notifyPriceApprovers] %v", err)
+	}
+}
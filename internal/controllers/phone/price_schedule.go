@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+	"gopkg.in/guregu/null.v4"
+)
+
+// GetScheduledPriceChanges lists the schedules of a phone that are pending or
+// waiting for their revert.
+func (c *PhoneController) GetScheduledPriceChanges(w http.ResponseWriter, r *http.Request) {
+	phone := c.phoneFromURL(r)
+
+	schedules, err := models.GetActiveScheduledPriceChanges(c.App.DB, phone.ID)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, schedules); err != nil {
+		panic(err)
+	}
+}
+
+// CreateScheduledPriceChange schedules a price change for a phone, optionally
+// reverting it later. Schedules overlapping an active one are rejected, and
+// schedules moving the price past the approval threshold wait for a second
+// admin like immediate price changes do.
+func (c *PhoneController) CreateScheduledPriceChange(w http.ResponseWriter, r *http.Request) {
+	phone := c.phoneFromURL(r)
+
+	var req CreateScheduledPriceChangeRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	schedule := models.ScheduledPriceChange{
+		PhoneID:       phone.ID,
+		Price:         req.Price,
+		EffectiveFrom: req.EffectiveFrom,
+		RevertAt:      req.RevertAt,
+	}
+
+	// The phone stays locked until the schedule is in, so concurrent
+	// requests check for overlaps one after the other
+	tx := c.App.DB.MustBegin()
+	if err := models.LockPhone(tx, phone.ID); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	active, err := models.GetActiveScheduledPriceChanges(tx, phone.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	for _, other := range active {
+		if schedule.Overlaps(other) {
+			_ = tx.Rollback()
+			panic(httperr.NewErrUnprocessableEntity("schedule_conflict", "the schedule overlaps another price change", other))
+		}
+	}
+
+	currentPrice, err := models.GetPhonePrice(tx, phone.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	var priceChangeRequest *models.PriceChangeRequest
+	if models.RequiresPriceApproval(currentPrice, schedule.Price, c.App.Config.PriceApproval.ThresholdPercent) {
+		schedule.Status = models.ScheduledPriceChangePendingApproval
+	}
+
+	if err := schedule.Insert(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if schedule.Status == models.ScheduledPriceChangePendingApproval {
+		priceChangeRequest = &models.PriceChangeRequest{
+			PhoneID:                phone.ID,
+			ScheduledPriceChangeID: null.IntFrom(int64(schedule.ID)),
+			OldPrice:               currentPrice,
+			NewPrice:               schedule.Price,
+			RequestedBy:            c.RequestContext(r).Auth.UserID(),
+		}
+		if err := priceChangeRequest.Insert(tx); err != nil {
+			_ = tx.Rollback()
+			panic(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	created, _, err := models.GetScheduledPriceChange(c.App.DB, phone.ID, schedule.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	if priceChangeRequest != nil {
+		c.notifyPriceApprovers(priceChangeRequest)
+		err = responses.JSON(w, http.StatusAccepted, map[string]any{
+			"schedule":             created,
+			"price_change_request": priceChangeRequest,
+		})
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if err := responses.JSON(w, http.StatusCreated, created); err != nil {
+		panic(err)
+	}
+}
+
+// CancelScheduledPriceChange cancels a schedule that has not been applied yet.
+func (c *PhoneController) CancelScheduledPriceChange(w http.ResponseWriter, r *http.Request) {
+	phone := c.phoneFromURL(r)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "ScheduleID"))
+	if err != nil {
+		panic(httperr.ErrNotFound)
+	}
+
+	tx := c.App.DB.MustBegin()
+	schedule, err := models.LockScheduledPriceChange(tx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && schedule.PhoneID != phone.ID) {
+		_ = tx.Rollback()
+		panic(httperr.ErrNotFound)
+	} else if err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if schedule.Status != models.ScheduledPriceChangePending {
+		_ = tx.Rollback()
+		panic(httperr.NewErrUnprocessableEntity("schedule_not_pending", "only pending schedules can be cancelled", nil))
+	}
+	if err := schedule.Cancel(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	if err := responses.JSON(w, http.StatusOK, schedule); err != nil {
+		panic(err)
+	}
+}
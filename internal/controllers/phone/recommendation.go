@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/recommendation"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+type RecommendedPhone struct {
+	Score  float64      `json:"score"`
+	Pinned bool         `json:"pinned"`
+	Phone  models.Phone `json:"phone"`
+}
+
+type RecommendationResponse struct {
+	Similar      []RecommendedPhone `json:"similar"`
+	AlsoConsider []RecommendedPhone `json:"also_consider"`
+}
+
+// GetRecommendations returns the "similar phones" and "you might also
+// consider" lists of a phone from the precomputed ranking.
+func (c *PhoneController) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	phone := c.phoneFromURL(r)
+	rate, err := c.displayCurrency(r)
+	if err != nil {
+		panic(err)
+	}
+	lang := c.catalogLanguage(r)
+
+	candidates, err := c.App.Recommender.Candidates(c.App.DB, phone)
+	if err != nil {
+		panic(err)
+	}
+	overrides, err := models.GetRecommendationOverrides(c.App.DB, phone.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	result := recommendation.Apply(phone, candidates, overrides, c.App.Recommender.Limit)
+	resp := RecommendationResponse{
+		Similar:      c.loadRecommendedPhones(result.Similar, rate, lang),
+		AlsoConsider: c.loadRecommendedPhones(result.AlsoConsider, rate, lang),
+	}
+	if err := responses.JSON(w, http.StatusOK, resp); err != nil {
+		panic(err)
+	}
+}
+
+// GetRecommendationOverrides lists the pinned and excluded recommendations of
+// a phone.
+func (c *PhoneController) GetRecommendationOverrides(w http.ResponseWriter, r *http.Request) {
+	phone := c.phoneFromURL(r)
+
+	overrides, err := models.GetRecommendationOverrides(c.App.DB, phone.ID)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, overrides); err != nil {
+		panic(err)
+	}
+}
+
+// UpdateRecommendationOverrides replaces the pinned and excluded
+// recommendations of a phone.
+func (c *PhoneController) UpdateRecommendationOverrides(w http.ResponseWriter, r *http.Request) {
+	phone := c.phoneFromURL(r)
+
+	req := UpdateRecommendationOverridesRequest{PhoneID: phone.ID}
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	tx := c.App.DB.MustBegin()
+	if err := models.ReplaceRecommendationOverrides(tx, phone.ID, req.Pinned, req.Excluded); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	overrides, err := models.GetRecommendationOverrides(c.App.DB, phone.ID)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, overrides); err != nil {
+		panic(err)
+	}
+}
+
+func (c *PhoneController) loadRecommendedPhones(candidates []recommendation.Candidate, rate *models.ExchangeRate, lang string) []RecommendedPhone {
+	now := time.Now()
+	phones := make([]models.Phone, 0, len(candidates))
+	found := make([]recommendation.Candidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		phone, err := models.GetPhone(c.App.DB, candidate.PhoneID)
+		if err != nil {
+			// A cached candidate may have been deleted since the ranking was built
+			c.App.Log.Warning(fmt.Sprintf("[PhoneController.loadRecommendedPhones] skipping phone %d: %v", candidate.PhoneID, err))
+			continue
+		}
+		// Nor can a cached or pinned phone be shown once it is unpublished
+		if !phone.IsPublished(now) {
+			continue
+		}
+		phones = append(phones, phone)
+		found = append(found, candidate)
+	}
+
+	if err := models.TranslatePhones(c.App.DB, phones, lang); err != nil {
+		panic(err)
+	}
+	setDisplayPrices(rate, phones)
+
+	recommended := make([]RecommendedPhone, 0, len(phones))
+	for i, phone := range phones {
+		recommended = append(recommended, RecommendedPhone{
+			Score:  found[i].Score,
+			Pinned: found[i].Pinned,
+			Phone:  phone,
+		})
+	}
+	return recommended
+}
+
+// refreshRecommendations rebuilds the cached rankings in the background after
+// a phone write.
+func (c *PhoneController) refreshRecommendations() {
+	c.App.Recommender.RefreshInBackground(c.App.DB, func(err error) {
+		c.App.Log.Errorf("[PhoneController.refreshRecommendations] %v", err)
+	})
+}
+
+func (c *PhoneController) phoneFromURL(r *http.Request) models.Phone {
+	phone, err := models.GetPhone(c.App.DB, phoneIDFromURL(r))
+	if err != nil {
+		panic(err)
+	}
+	return phone
+}
+
+func phoneIDFromURL(r *http.Request) int {
+	id, err := strconv.Atoi(chi.URLParam(r, "PhoneID"))
+	if err != nil {
+		panic(httperr.ErrNotFound)
+	}
+	return id
+}
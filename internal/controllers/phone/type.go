@@ -0,0 +1,319 @@
+package controller
+
+import (
+	"strconv"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+type UpdateRecommendationOverridesRequest struct {
+	PhoneID  int   `json:"-"`
+	Pinned   []int `json:"pinned"`
+	Excluded []int `json:"excluded"`
+}
+
+func (r *UpdateRecommendationOverridesRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionPhoneUpdate)
+}
+
+func (r *UpdateRecommendationOverridesRequest) Validate(ctx *reqdata.Context) error {
+	pinned := map[int]bool{}
+	for _, id := range r.Pinned {
+		pinned[id] = true
+	}
+
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Pinned, validation.By(existingOtherPhones(ctx, r.PhoneID, true))),
+		validation.Field(&r.Excluded, validation.By(existingOtherPhones(ctx, r.PhoneID, false)), validation.By(func(value interface{}) error {
+			for _, id := range value.([]int) {
+				if pinned[id] {
+					return validation.NewError("invalid_pinned_excluded", "phone {{.id}} cannot be pinned and excluded at the same time").
+						SetParams(map[string]any{"id": id})
+				}
+			}
+			return nil
+		})),
+	)
+}
+
+// existingOtherPhones checks that every ID in a []int refers to an existing
+// phone other than the one being edited, and appears only once. With
+// published the phones must also be published; phones can be excluded ahead
+// of their launch but not pinned.
+func existingOtherPhones(ctx *reqdata.Context, self int, published bool) validation.RuleFunc {
+	return func(value interface{}) error {
+		now := time.Now()
+		seen := map[int]bool{}
+		for _, id := range value.([]int) {
+			if id == self {
+				return validation.NewError("invalid_self_reference", "a phone cannot reference itself")
+			}
+			if seen[id] {
+				return validation.NewError("invalid_duplicate_id", "phone {{.id}} is listed more than once").SetParams(map[string]any{"id": id})
+			}
+			seen[id] = true
+			phone, err := models.GetPhone(ctx.App.DB, id)
+			if err != nil {
+				return validation.NewError("invalid_phone_id", "phone {{.id}} does not exist").SetParams(map[string]any{"id": id})
+			}
+			if published && !phone.IsPublished(now) {
+				return validation.NewError("invalid_unpublished_phone", "phone {{.id}} is not published").SetParams(map[string]any{"id": id})
+			}
+		}
+		return nil
+	}
+}
+
+type UpdateCompatibleAccessoriesRequest struct {
+	PhoneID      int   `json:"-"`
+	AccessoryIDs []int `json:"accessory_ids"`
+}
+
+func (r *UpdateCompatibleAccessoriesRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionPhoneUpdate)
+}
+
+func (r *UpdateCompatibleAccessoriesRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.AccessoryIDs, validation.NotNil, validation.By(phonesOfKind(ctx, r.PhoneID, true))),
+	)
+}
+
+type UpdateCompatibleDevicesRequest struct {
+	AccessoryID int   `json:"-"`
+	PhoneIDs    []int `json:"phone_ids"`
+}
+
+func (r *UpdateCompatibleDevicesRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionPhoneUpdate)
+}
+
+func (r *UpdateCompatibleDevicesRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.PhoneIDs, validation.NotNil, validation.By(phonesOfKind(ctx, r.AccessoryID, false))),
+	)
+}
+
+type CopyCompatibilityRequest struct {
+	PhoneID     int `json:"-"`
+	FromPhoneID int `json:"from_phone_id"`
+}
+
+func (r *CopyCompatibilityRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionPhoneUpdate)
+}
+
+func (r *CopyCompatibilityRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.FromPhoneID, validation.Required, validation.By(func(value interface{}) error {
+			return phonesOfKind(ctx, r.PhoneID, false)([]int{value.(int)})
+		})),
+	)
+}
+
+// phonesOfKind checks that every ID in a []int refers to an existing phone
+// other than self which is an accessory when accessory is true, or a device
+// otherwise.
+func phonesOfKind(ctx *reqdata.Context, self int, accessory bool) validation.RuleFunc {
+	return func(value interface{}) error {
+		for _, id := range value.([]int) {
+			if id == self {
+				return validation.NewError("invalid_self_reference", "a phone cannot reference itself")
+			}
+			phone, err := models.GetPhone(ctx.App.DB, id)
+			if err != nil {
+				return validation.NewError("invalid_phone_id", "phone {{.id}} does not exist").SetParams(map[string]any{"id": id})
+			}
+			if phone.IsAccessory() != accessory {
+				if accessory {
+					return validation.NewError("invalid_accessory", "phone {{.id}} is not tagged as {{.tag}}").
+						SetParams(map[string]any{"id": id, "tag": models.TagAccessories})
+				}
+				return validation.NewError("invalid_device", "phone {{.id}} is an accessory").SetParams(map[string]any{"id": id})
+			}
+		}
+		return nil
+	}
+}
+
+type CreateScheduledPriceChangeRequest struct {
+	Price         money.Amount `json:"price"`
+	EffectiveFrom time.Time    `json:"effective_from"`
+	RevertAt      *time.Time   `json:"revert_at"`
+}
+
+func (r *CreateScheduledPriceChangeRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionPhoneUpdate)
+}
+
+func (r *CreateScheduledPriceChangeRequest) Validate(_ *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Price, validation.By(money.Positive)),
+		validation.Field(&r.EffectiveFrom, validation.Required, validation.Min(time.Now())),
+		validation.Field(&r.RevertAt, validation.NilOrNotEmpty, validation.When(r.RevertAt != nil, validation.Min(r.EffectiveFrom).Exclusive())),
+	)
+}
+
+type BulkEditRequest struct {
+	Selector           models.BulkSelector    `json:"selector"`
+	Operations         []models.BulkOperation `json:"operations"`
+	DryRun             bool                   `json:"dry_run"`
+	ConfirmPriceChange bool                   `json:"confirm_price_change"`
+}
+
+func (r *BulkEditRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionPhoneUpdate)
+}
+
+func (r *BulkEditRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Selector, validation.By(func(value interface{}) error {
+			selector := value.(models.BulkSelector)
+			if len(selector.IDs) == 0 && selector.FilterBy == "" && selector.BrandID == 0 {
+				return validation.NewError("invalid_selector", "select phones by ids, brand_id or filter_by")
+			}
+			if _, _, err := models.PhoneFilterClause(selector.FilterBy, selector.FilterValue); err != nil {
+				return validation.NewError("invalid_filter_by", "unknown filter_by {{.filter_by}}").
+					SetParams(map[string]any{"filter_by": selector.FilterBy})
+			}
+			return nil
+		})),
+		validation.Field(&r.Operations, validation.Required, validation.By(func(value interface{}) error {
+			errs := validation.Errors{}
+			for i, op := range value.([]models.BulkOperation) {
+				if err := validateBulkOperation(ctx, op); err != nil {
+					errs[strconv.Itoa(i)] = err
+				}
+			}
+			return errs.Filter()
+		})),
+	)
+}
+
+func validateBulkOperation(ctx *reqdata.Context, op models.BulkOperation) error {
+	switch op.Op {
+	case models.BulkOpAddTag, models.BulkOpRemoveTag:
+		if _, err := models.GetTag(ctx.App.DB, op.TagID); err != nil {
+			return validation.NewError("invalid_tag_id", "tag {{.id}} does not exist").SetParams(map[string]any{"id": op.TagID})
+		}
+	case models.BulkOpSetPublishedAt:
+	case models.BulkOpAdjustPrice:
+		if op.Percent <= -100 {
+			return validation.ErrMinGreaterThanRequired.SetParams(map[string]any{"threshold": -100})
+		}
+	case models.BulkOpSetPrice:
+		if op.Price <= 0 {
+			return validation.ErrMinGreaterThanRequired.SetParams(map[string]any{"threshold": 0})
+		}
+	default:
+		return validation.NewError("invalid_op", "unknown op {{.op}}").SetParams(map[string]any{"op": op.Op})
+	}
+	return nil
+}
+
+// PhoneRequest holds the fields shared by phone creation and update.
+type PhoneRequest struct {
+	Name               string        `json:"name"`
+	BrandID            int           `json:"brand_id"`
+	Specifications     string        `json:"specifications"`
+	Price              money.Amount  `json:"price"`
+	CostPrice          *money.Amount `json:"cost_price"`
+	Tags               []models.Tag  `json:"tags"`
+	ConfirmPriceChange bool          `json:"confirm_price_change"`
+}
+
+// validate checks the fields, phoneID being the phone updated or 0 for a new
+// phone.
+func (r *PhoneRequest) validate(ctx *reqdata.Context, phoneID int) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 255), validation.By(func(value interface{}) error {
+			if r.BrandID == 0 {
+				return nil
+			}
+			exists, err := models.PhoneNameExists(ctx.App.DB, r.BrandID, value.(string), phoneID)
+			if err != nil {
+				return validation.NewInternalError(err)
+			} else if exists {
+				return validation.NewError("invalid_name_taken", "the brand already has a phone named {{.name}}").
+					SetParams(map[string]any{"name": value})
+			}
+			return nil
+		})),
+		validation.Field(&r.BrandID, validation.Required, validation.By(func(value interface{}) error {
+			if _, err := models.GetBrand(ctx.App.DB, value.(int)); err != nil {
+				return validation.NewError("invalid_brand_id", "brand does not exist")
+			}
+			return nil
+		})),
+		validation.Field(&r.Specifications, validation.Length(0, 65535)),
+		validation.Field(&r.Price, validation.By(money.Positive)),
+		validation.Field(&r.CostPrice, validation.By(money.NotNegative)),
+		validation.Field(&r.Tags, validation.By(func(value interface{}) error {
+			for _, tag := range value.([]models.Tag) {
+				if _, err := models.GetTag(ctx.App.DB, tag.ID); err != nil {
+					return validation.NewError("invalid_tag_id", "tag {{.id}} does not exist").SetParams(map[string]any{"id": tag.ID})
+				}
+			}
+			return nil
+		})),
+	)
+}
+
+func (r *PhoneRequest) phone() models.Phone {
+	tags := make([]models.Tag, 0, len(r.Tags))
+	for _, tag := range r.Tags {
+		tags = append(tags, models.Tag{ID: tag.ID})
+	}
+	return models.Phone{
+		Name:               r.Name,
+		BrandID:            r.BrandID,
+		Specifications:     r.Specifications,
+		Price:              r.Price,
+		CostPrice:          r.CostPrice,
+		Tags:               tags,
+		ConfirmPriceChange: r.ConfirmPriceChange,
+	}
+}
+
+type CreatePhoneRequest struct {
+	PhoneRequest
+}
+
+func (r *CreatePhoneRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionPhoneCreate)
+}
+
+func (r *CreatePhoneRequest) Validate(ctx *reqdata.Context) error {
+	return r.validate(ctx, 0)
+}
+
+func (r *CreatePhoneRequest) Phone() models.Phone {
+	return r.phone()
+}
+
+// UpdatePhoneRequest replaces every field of a phone.
+type UpdatePhoneRequest struct {
+	PhoneID int `json:"-"`
+	PhoneRequest
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+func (r *UpdatePhoneRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionPhoneUpdate)
+}
+
+func (r *UpdatePhoneRequest) Validate(ctx *reqdata.Context) error {
+	return r.validate(ctx, r.PhoneID)
+}
+
+func (r *UpdatePhoneRequest) Phone() models.Phone {
+	phone := r.phone()
+	phone.ID = r.PhoneID
+	phone.PublishedAt = r.PublishedAt
+	return phone
+}
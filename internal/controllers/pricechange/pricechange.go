@@ -0,0 +1,118 @@
+package pricechange
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+type PriceChangeController struct {
+	controllers.Controller
+}
+
+func NewPriceChangeController(app *app.Registry) *PriceChangeController {
+	return &PriceChangeController{controllers.Controller{App: app}}
+}
+
+func (c *PriceChangeController) GetPriceChangeRequests(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if err := validation.Validate(status, validation.In(models.TransactionRequestPending, models.TransactionRequestApproved, models.TransactionRequestRejected)); err != nil {
+		panic(validation.Errors{"status": err})
+	}
+
+	requests, err := models.GetPriceChangeRequests(c.App.DB, status)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, requests); err != nil {
+		panic(err)
+	}
+}
+
+func (c *PriceChangeController) GetPriceChangeRequest(w http.ResponseWriter, r *http.Request) {
+	req, found, err := models.GetPriceChangeRequestByID(c.App.DB, chi.URLParam(r, "RequestID"))
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrNotFound)
+	}
+	if err := responses.JSON(w, http.StatusOK, req); err != nil {
+		panic(err)
+	}
+}
+
+// Approve applies a pending price change. The approver has to be a different
+// admin than the one who asked for the change.
+func (c *PriceChangeController) Approve(w http.ResponseWriter, r *http.Request) {
+	c.review(w, r, models.TransactionRequestApproved)
+}
+
+func (c *PriceChangeController) Reject(w http.ResponseWriter, r *http.Request) {
+	c.review(w, r, models.TransactionRequestRejected)
+}
+
+func (c *PriceChangeController) review(w http.ResponseWriter, r *http.Request, status string) {
+	var form ReviewPriceChangeRequest
+	if err := c.Validate(&form, r); err != nil {
+		panic(err)
+	}
+	reviewerID := c.RequestContext(r).Auth.UserID()
+
+	tx := c.App.DB.MustBegin()
+	req, found, err := models.LockPriceChangeRequest(tx, chi.URLParam(r, "RequestID"))
+	if err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	} else if !found {
+		_ = tx.Rollback()
+		panic(httperr.ErrNotFound)
+	}
+
+	if req.Status != models.TransactionRequestPending {
+		_ = tx.Rollback()
+		panic(httperr.NewErrUnprocessableEntity("price_change_request_reviewed", "the price change request has already been reviewed", nil))
+	}
+	if req.RequestedBy == reviewerID {
+		_ = tx.Rollback()
+		panic(httperr.NewErrUnprocessableEntity("price_change_request_own", "a price change cannot be reviewed by the admin who requested it", nil))
+	}
+
+	if status == models.TransactionRequestApproved {
+		rules, rulesErr := models.GetEnabledPricingRules(tx)
+		if rulesErr != nil {
+			_ = tx.Rollback()
+			panic(rulesErr)
+		}
+		err = req.Approve(tx, reviewerID, form.Note, rules)
+	} else {
+		err = req.Reject(tx, reviewerID, form.Note)
+	}
+	if errors.Is(err, models.ErrPriceChangeRequestStale) {
+		_ = tx.Rollback()
+		panic(httperr.NewErrUnprocessableEntity("price_change_request_stale", "the phone price changed since the request was made", nil))
+	} else if err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	// Approved schedules change the price later, through the scheduler
+	if status == models.TransactionRequestApproved && !req.ScheduledPriceChangeID.Valid {
+		c.App.Recommender.RefreshInBackground(c.App.DB, func(err error) {
+			c.App.Log.Errorf("[PriceChangeController.review] %v", err)
+		})
+	}
+
+	if err := responses.JSON(w, http.StatusOK, req); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,24 @@
+package pricechange
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+)
+
+type ReviewPriceChangeRequest struct {
+	Note string `json:"note"`
+}
+
+// Authorized only lets admins holding the approval permission review
+// requests.
+func (r *ReviewPriceChangeRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.IsAdminAuthorized(ctx, models.PermissionPriceApprove)
+}
+
+func (r *ReviewPriceChangeRequest) Validate(_ *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Note, validation.Length(0, 1000)),
+	)
+}
@@ -0,0 +1,159 @@
+package pricerule
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/pricing"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+type PriceRuleController struct {
+	controllers.Controller
+}
+
+func NewPriceRuleController(app *app.Registry) *PriceRuleController {
+	return &PriceRuleController{controllers.Controller{App: app}}
+}
+
+func (c *PriceRuleController) GetPriceRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := models.GetPriceRules(c.App.DB)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, rules); err != nil {
+		panic(err)
+	}
+}
+
+func (c *PriceRuleController) CreatePriceRule(w http.ResponseWriter, r *http.Request) {
+	var req PriceRuleRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	rule := req.PriceRule()
+	tx := c.App.DB.MustBegin()
+	if err := rule.Insert(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.respondWithPriceRule(w, http.StatusCreated, rule.ID)
+}
+
+func (c *PriceRuleController) UpdatePriceRule(w http.ResponseWriter, r *http.Request) {
+	existing := c.priceRuleFromURL(r)
+
+	var req PriceRuleRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	rule := req.PriceRule()
+	rule.ID = existing.ID
+	tx := c.App.DB.MustBegin()
+	if err := rule.Update(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.respondWithPriceRule(w, http.StatusOK, rule.ID)
+}
+
+func (c *PriceRuleController) DeletePriceRule(w http.ResponseWriter, r *http.Request) {
+	rule := c.priceRuleFromURL(r)
+
+	tx := c.App.DB.MustBegin()
+	if err := rule.Delete(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Preview runs the pricing rules on a price and reports which rules adjusted
+// it, without changing any phone.
+func (c *PriceRuleController) Preview(w http.ResponseWriter, r *http.Request) {
+	var req PreviewRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	input := pricing.Input{
+		Price:         req.Price,
+		CostPrice:     req.CostPrice,
+		PreviousPrice: req.PreviousPrice,
+		BrandID:       req.BrandID,
+	}
+	if req.PhoneID != nil {
+		phone, err := models.GetPhone(c.App.DB, *req.PhoneID)
+		if err != nil {
+			panic(err)
+		}
+		input.BrandID = phone.BrandID
+		input.PreviousPrice = &phone.Price
+		if input.CostPrice == nil {
+			input.CostPrice = phone.CostPrice
+		}
+	}
+
+	var rules []pricing.Rule
+	if req.RuleID != nil {
+		rule, _, err := models.GetPriceRule(c.App.DB, *req.RuleID)
+		if err != nil {
+			panic(err)
+		}
+		rules = []pricing.Rule{rule.Rule()}
+	} else {
+		var err error
+		rules, err = models.GetEnabledPricingRules(c.App.DB)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if err := responses.JSON(w, http.StatusOK, pricing.Evaluate(rules, input)); err != nil {
+		panic(err)
+	}
+}
+
+func (c *PriceRuleController) respondWithPriceRule(w http.ResponseWriter, status int, id int) {
+	rule, _, err := models.GetPriceRule(c.App.DB, id)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, status, rule); err != nil {
+		panic(err)
+	}
+}
+
+func (c *PriceRuleController) priceRuleFromURL(r *http.Request) *models.PriceRule {
+	id, err := strconv.Atoi(chi.URLParam(r, "RuleID"))
+	if err != nil {
+		panic(httperr.ErrNotFound)
+	}
+
+	rule, found, err := models.GetPriceRule(c.App.DB, id)
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrNotFound)
+	}
+	return rule
+}
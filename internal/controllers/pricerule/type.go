@@ -0,0 +1,100 @@
+package pricerule
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/pricing"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+type PriceRuleRequest struct {
+	Name     string       `json:"name"`
+	RuleType string       `json:"rule_type"`
+	Value    money.Amount `json:"value"`
+	BrandID  *int         `json:"brand_id"`
+	Enabled  *bool        `json:"enabled"`
+}
+
+func (r *PriceRuleRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionPriceRuleManage)
+}
+
+func (r *PriceRuleRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 255)),
+		validation.Field(&r.RuleType, validation.Required, validation.In(pricing.RuleRounding, pricing.RuleMinMargin, pricing.RuleMaxStep)),
+		validation.Field(&r.Value, validation.By(money.Positive)),
+		validation.Field(&r.BrandID, validation.By(existingBrand(ctx))),
+	)
+}
+
+func (r *PriceRuleRequest) PriceRule() models.PriceRule {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return models.PriceRule{
+		Name:     r.Name,
+		RuleType: r.RuleType,
+		Value:    r.Value,
+		BrandID:  r.BrandID,
+		Enabled:  enabled,
+	}
+}
+
+// PreviewRequest evaluates the pricing rules on a price without saving it.
+// The cost price, previous price and brand are taken from PhoneID when it is
+// set, and RuleID restricts the preview to a single rule.
+type PreviewRequest struct {
+	Price         money.Amount  `json:"price"`
+	CostPrice     *money.Amount `json:"cost_price"`
+	PreviousPrice *money.Amount `json:"previous_price"`
+	BrandID       int           `json:"brand_id"`
+	PhoneID       *int          `json:"phone_id"`
+	RuleID        *int          `json:"rule_id"`
+}
+
+func (r *PreviewRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionPriceRuleManage)
+}
+
+func (r *PreviewRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Price, validation.By(money.Positive)),
+		validation.Field(&r.PhoneID, validation.By(func(value interface{}) error {
+			id, _ := value.(*int)
+			if id == nil {
+				return nil
+			}
+			if _, err := models.GetPhone(ctx.App.DB, *id); err != nil {
+				return validation.NewError("invalid_phone_id", "phone {{.id}} does not exist").SetParams(map[string]any{"id": *id})
+			}
+			return nil
+		})),
+		validation.Field(&r.RuleID, validation.By(func(value interface{}) error {
+			id, _ := value.(*int)
+			if id == nil {
+				return nil
+			}
+			if _, found, err := models.GetPriceRule(ctx.App.DB, *id); err != nil || !found {
+				return validation.NewError("invalid_rule_id", "price rule does not exist")
+			}
+			return nil
+		})),
+	)
+}
+
+func existingBrand(ctx *reqdata.Context) validation.RuleFunc {
+	return func(value interface{}) error {
+		id, _ := value.(*int)
+		if id == nil {
+			return nil
+		}
+		if _, err := models.GetBrand(ctx.App.DB, *id); err != nil {
+			return validation.NewError("invalid_brand_id", "brand does not exist")
+		}
+		return nil
+	}
+}
@@ -3,7 +3,6 @@ package public
 import (
 	"net/http"
 
-	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
 
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
@@ -19,12 +18,10 @@ func NewKeysController(app *app.Registry) *KeysController {
 }
 
 func (c *KeysController) Keys(w http.ResponseWriter, r *http.Request) {
-	publicKey := c.App.VerifyKey
-	err := responses.JSON(w, 200, struct {
-		Keys []jwk.Key `json:"keys"`
-	}{
-		Keys: []jwk.Key{publicKey},
-	})
+	// Clients may cache the keys for a while, a key is only retired once
+	// what it signed has expired anyway
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	err := responses.JSON(w, 200, c.App.VerifyKeys)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,232 @@
+package role
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/mq"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/permission"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+type RoleController struct {
+	controllers.Controller
+}
+
+func NewRoleController(app *app.Registry) *RoleController {
+	return &RoleController{controllers.Controller{App: app}}
+}
+
+// GetPermissions lists every permission grouped by module.
+func (c *RoleController) GetPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, _, err := models.GetAllPermissions(c.App.DB)
+	if err != nil {
+		panic(err)
+	}
+
+	sort.Slice(permissions, func(i, j int) bool {
+		if permissions[i].Module != permissions[j].Module {
+			return permissions[i].Module < permissions[j].Module
+		}
+		return permissions[i].Identifier < permissions[j].Identifier
+	})
+
+	groups := []PermissionGroup{}
+	for _, p := range permissions {
+		if len(groups) == 0 || groups[len(groups)-1].Module != p.Module {
+			groups = append(groups, PermissionGroup{Module: p.Module})
+		}
+		last := &groups[len(groups)-1]
+		last.Permissions = append(last.Permissions, p)
+	}
+
+	if err := responses.JSON(w, http.StatusOK, groups); err != nil {
+		panic(err)
+	}
+}
+
+func (c *RoleController) GetRoles(w http.ResponseWriter, r *http.Request) {
+	roles, found, err := models.GetRoles(c.App.DB, "name", true, models.Role{Name: r.URL.Query().Get("name")})
+	if err != nil {
+		panic(err)
+	} else if !found {
+		roles = []models.Role{}
+	}
+	if err := responses.JSON(w, http.StatusOK, roles); err != nil {
+		panic(err)
+	}
+}
+
+func (c *RoleController) GetRole(w http.ResponseWriter, r *http.Request) {
+	role := c.roleFromURL(r)
+	c.respondWithRole(w, http.StatusOK, role.ID)
+}
+
+func (c *RoleController) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req CreateRoleRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	role := req.Role()
+	tx := c.App.DB.MustBegin()
+	if err := role.Insert(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := role.AdjustAssignedPermissions(tx, distinct(req.PermissionIDs)); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.respondWithRole(w, http.StatusCreated, role.ID)
+}
+
+func (c *RoleController) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	role := c.roleFromURL(r)
+
+	req := UpdateRoleRequest{RoleID: role.ID}
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	role.Name = req.Name
+	tx := c.App.DB.MustBegin()
+	if err := role.Update(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	c.publishAdminsUpdated(role.ID)
+	c.respondWithRole(w, http.StatusOK, role.ID)
+}
+
+// UpdateRolePermissions replaces the permissions of a role in a single
+// transaction, admins of the role see the change on their next request.
+func (c *RoleController) UpdateRolePermissions(w http.ResponseWriter, r *http.Request) {
+	role := c.roleFromURL(r)
+
+	var req UpdateRolePermissionsRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	tx := c.App.DB.MustBegin()
+	if err := role.AdjustAssignedPermissions(tx, distinct(req.PermissionIDs)); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	if err := permission.Invalidate(c.App.Cache, role.ID); err != nil {
+		panic(err)
+	}
+	c.publishAdminsUpdated(role.ID)
+	c.respondWithRole(w, http.StatusOK, role.ID)
+}
+
+// DeleteRole refuses to delete a role still given to admins, they have to be
+// moved to another role first.
+func (c *RoleController) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	role := c.roleFromURL(r)
+
+	tx := c.App.DB.MustBegin()
+	count, err := models.CountRoleAdminsForUpdate(tx, role.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	} else if count > 0 {
+		_ = tx.Rollback()
+		panic(httperr.NewErrUnprocessableEntity("role_in_use", "role is still given to admins", map[string]any{
+			"admin_count": count,
+		}))
+	}
+	if err := role.Delete(tx); errors.Is(err, models.ErrRoleInUse) {
+		_ = tx.Rollback()
+		panic(httperr.NewErrUnprocessableEntity("role_in_use", "role is still given to admins", nil))
+	} else if err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	if err := permission.Invalidate(c.App.Cache, role.ID); err != nil {
+		panic(err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *RoleController) GetRoleAdmins(w http.ResponseWriter, r *http.Request) {
+	role := c.roleFromURL(r)
+
+	admins, found, err := models.GetAdminsByRoleID(c.App.DB, role.ID)
+	if err != nil {
+		panic(err)
+	} else if !found {
+		admins = []models.Admin{}
+	}
+	if err := responses.JSON(w, http.StatusOK, admins); err != nil {
+		panic(err)
+	}
+}
+
+// publishAdminsUpdated tells the other services that the admins of a role
+// changed. The role change is saved already, a failed notification is only
+// logged.
+func (c *RoleController) publishAdminsUpdated(roleID string) {
+	admins, _, err := models.GetAdminsByRoleID(c.App.DB, roleID)
+	if err != nil {
+		c.App.Log.Errorf("[RoleController.publishAdminsUpdated] %v", err)
+		return
+	}
+
+	for _, admin := range admins {
+		err := mq.PublishMessage(c.App.MessageProducer, mq.AdminUpdatedTopic, mq.AdminUpdatedMsg{
+			AdminID: admin.ID,
+		})
+		if err != nil {
+			c.App.Log.Errorf("[RoleController.publishAdminsUpdated] %v", err)
+		}
+	}
+}
+
+func (c *RoleController) respondWithRole(w http.ResponseWriter, status int, id string) {
+	role, found, err := models.GetRoleByID(c.App.DB, id)
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrNotFound)
+	}
+	if err := role.LoadPermissions(c.App.DB); err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, status, role); err != nil {
+		panic(err)
+	}
+}
+
+func (c *RoleController) roleFromURL(r *http.Request) *models.Role {
+	role, found, err := models.GetRoleByID(c.App.DB, chi.URLParam(r, "RoleID"))
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrNotFound)
+	}
+	return role
+}
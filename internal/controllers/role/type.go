@@ -0,0 +1,119 @@
+package role
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+)
+
+// PermissionGroup lists the permissions of a module.
+type PermissionGroup struct {
+	Module      string              `json:"module"`
+	Permissions []models.Permission `json:"permissions"`
+}
+
+type CreateRoleRequest struct {
+	Name          string  `json:"name"`
+	PermissionIDs []int64 `json:"permission_ids"`
+}
+
+func (r *CreateRoleRequest) Authorized(_ *reqdata.Context) bool {
+	return true
+}
+
+func (r *CreateRoleRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 100), validation.By(uniqueName(ctx, ""))),
+		validation.Field(&r.PermissionIDs, validation.By(existingPermissions(ctx))),
+	)
+}
+
+func (r *CreateRoleRequest) Role() models.Role {
+	return models.Role{Name: r.Name}
+}
+
+// UpdateRoleRequest renames a role, its permissions are set through
+// UpdateRolePermissionsRequest.
+type UpdateRoleRequest struct {
+	RoleID string `json:"-"`
+	Name   string `json:"name"`
+}
+
+func (r *UpdateRoleRequest) Authorized(_ *reqdata.Context) bool {
+	return true
+}
+
+func (r *UpdateRoleRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 100), validation.By(uniqueName(ctx, r.RoleID))),
+	)
+}
+
+// UpdateRolePermissionsRequest replaces every permission of a role, an empty
+// list removes them all.
+type UpdateRolePermissionsRequest struct {
+	PermissionIDs []int64 `json:"permission_ids"`
+}
+
+func (r *UpdateRolePermissionsRequest) Authorized(_ *reqdata.Context) bool {
+	return true
+}
+
+func (r *UpdateRolePermissionsRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.PermissionIDs, validation.By(existingPermissions(ctx))),
+	)
+}
+
+func uniqueName(ctx *reqdata.Context, exceptID string) validation.RuleFunc {
+	return func(value interface{}) error {
+		name, _ := value.(string)
+		exists, err := models.RoleNameExists(ctx.App.DB, name, exceptID)
+		if err != nil {
+			return validation.NewInternalError(err)
+		} else if exists {
+			return validation.NewError("invalid_role_name_taken", "a role named {{.name}} already exists").
+				SetParams(map[string]any{"name": name})
+		}
+		return nil
+	}
+}
+
+func existingPermissions(ctx *reqdata.Context) validation.RuleFunc {
+	return func(value interface{}) error {
+		ids, _ := value.([]int64)
+		if len(ids) == 0 {
+			return nil
+		}
+
+		permissions, _, err := models.GetAllPermissions(ctx.App.DB)
+		if err != nil {
+			return validation.NewInternalError(err)
+		}
+		known := make(map[int64]bool, len(permissions))
+		for _, permission := range permissions {
+			known[permission.ID] = true
+		}
+
+		for _, id := range ids {
+			if !known[id] {
+				return validation.NewError("invalid_permission_id", "permission {{.id}} does not exist").
+					SetParams(map[string]any{"id": id})
+			}
+		}
+		return nil
+	}
+}
+
+// distinct drops the repeated ids, each permission is assigned once.
+func distinct(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,165 @@
+package system
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+	"gopkg.in/guregu/null.v4"
+)
+
+type SystemController struct {
+	controllers.Controller
+}
+
+func NewSystemController(app *app.Registry) *SystemController {
+	return &SystemController{controllers.Controller{App: app}}
+}
+
+func (c *SystemController) GetSystems(w http.ResponseWriter, r *http.Request) {
+	systems, err := models.GetSystems(c.App.DB)
+	if err != nil {
+		panic(err)
+	}
+	for i := range systems {
+		if err := systems[i].LoadScopes(c.App.DB); err != nil {
+			panic(err)
+		}
+	}
+	if err := responses.JSON(w, http.StatusOK, systems); err != nil {
+		panic(err)
+	}
+}
+
+func (c *SystemController) GetSystem(w http.ResponseWriter, r *http.Request) {
+	system := c.systemFromURL(r)
+	if err := responses.JSON(w, http.StatusOK, system); err != nil {
+		panic(err)
+	}
+}
+
+// UpdateScopes replaces the scopes granted to a system. The tokens it holds
+// carry the old scopes, they are revoked so that the system gets new ones.
+func (c *SystemController) UpdateScopes(w http.ResponseWriter, r *http.Request) {
+	system := c.systemFromURL(r)
+
+	var req UpdateScopesRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	tx := c.App.DB.MustBegin()
+	if err := models.SetSystemScopes(tx, system.ID, req.Scopes); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+	if err := c.App.Auth.RevokeSystem(system.ID); err != nil {
+		panic(err)
+	}
+
+	if err := system.LoadScopes(c.App.DB); err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, system); err != nil {
+		panic(err)
+	}
+}
+
+// CreateSystem registers a system, the response holds its secret which
+// cannot be read again.
+func (c *SystemController) CreateSystem(w http.ResponseWriter, r *http.Request) {
+	var req CreateSystemRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	secret := models.GenerateSecretKey()
+	system := models.System{
+		Name:           req.Name,
+		URL:            req.URL,
+		PlainSecretKey: null.StringFrom(secret),
+	}
+	tx := c.App.DB.MustBegin()
+	if err := system.Insert(tx); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := models.SetSystemScopes(tx, system.ID, req.Scopes); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+	system.Scopes = req.Scopes
+
+	if err := responses.JSON(w, http.StatusCreated, SystemWithSecret{system, secret}); err != nil {
+		panic(err)
+	}
+}
+
+// RotateSecret gives a system a new secret, shown once in the response. The
+// old secret keeps working during the grace period so the system can be
+// redeployed with the new one.
+func (c *SystemController) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	system := c.systemFromURL(r)
+	if system.IsRevoked() {
+		panic(httperr.NewErrUnprocessableEntity("system_revoked", "system has been revoked", nil))
+	}
+
+	var req RotateSecretRequest
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	secret := system.RotateSecretKey(time.Duration(req.GracePeriodSeconds) * time.Second)
+	if err := system.Update(c.App.DB); err != nil {
+		panic(err)
+	}
+
+	if err := responses.JSON(w, http.StatusOK, SystemWithSecret{*system, secret}); err != nil {
+		panic(err)
+	}
+}
+
+// RevokeSystem stops a system from getting tokens and revokes the tokens it
+// holds.
+func (c *SystemController) RevokeSystem(w http.ResponseWriter, r *http.Request) {
+	system := c.systemFromURL(r)
+
+	if !system.IsRevoked() {
+		system.Revoke()
+		if err := system.Update(c.App.DB); err != nil {
+			panic(err)
+		}
+	}
+	// Also run for revoked systems, in case an earlier revocation failed
+	if err := c.App.Auth.RevokeSystem(system.ID); err != nil {
+		panic(err)
+	}
+
+	if err := responses.JSON(w, http.StatusOK, system); err != nil {
+		panic(err)
+	}
+}
+
+func (c *SystemController) systemFromURL(r *http.Request) *models.System {
+	system, found, err := models.GetSystemByID(c.App.DB, chi.URLParam(r, "SystemID"))
+	if err != nil {
+		panic(err)
+	} else if !found {
+		panic(httperr.ErrNotFound)
+	}
+	if err := system.LoadScopes(c.App.DB); err != nil {
+		panic(err)
+	}
+	return system
+}
@@ -0,0 +1,88 @@
+package system
+
+import (
+	"net/url"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+)
+
+// maxGracePeriodSeconds bounds how long a rotated secret keeps working.
+const maxGracePeriodSeconds = 7 * 24 * 60 * 60
+
+// SystemWithSecret is a system with its plain secret, only ever shown right
+// after the secret is generated.
+type SystemWithSecret struct {
+	models.System
+	Secret string `json:"secret"`
+}
+
+type CreateSystemRequest struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Scopes []string `json:"scopes"`
+}
+
+func (r *CreateSystemRequest) Authorized(_ *reqdata.Context) bool {
+	return true
+}
+
+func (r *CreateSystemRequest) Validate(_ *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 255)),
+		validation.Field(&r.URL, validation.Length(0, 255), validation.By(absoluteURL)),
+		validation.Field(&r.Scopes, validation.Each(validation.By(knownScope))),
+	)
+}
+
+// UpdateScopesRequest replaces the scopes granted to a system.
+type UpdateScopesRequest struct {
+	Scopes []string `json:"scopes"`
+}
+
+func (r *UpdateScopesRequest) Authorized(_ *reqdata.Context) bool {
+	return true
+}
+
+func (r *UpdateScopesRequest) Validate(_ *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Scopes, validation.Each(validation.By(knownScope))),
+	)
+}
+
+// RotateSecretRequest replaces the secret of a system, the old one keeps
+// working for GracePeriodSeconds.
+type RotateSecretRequest struct {
+	GracePeriodSeconds int `json:"grace_period_seconds"`
+}
+
+func (r *RotateSecretRequest) Authorized(_ *reqdata.Context) bool {
+	return true
+}
+
+func (r *RotateSecretRequest) Validate(_ *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.GracePeriodSeconds, validation.Min(0), validation.Max(maxGracePeriodSeconds)),
+	)
+}
+
+func absoluteURL(value interface{}) error {
+	raw, _ := value.(string)
+	if raw == "" {
+		return nil
+	}
+	if u, err := url.Parse(raw); err != nil || !u.IsAbs() || u.Host == "" {
+		return validation.NewError("invalid_url", "must be an absolute URL")
+	}
+	return nil
+}
+
+func knownScope(value interface{}) error {
+	scope, _ := value.(string)
+	if !models.IsSystemScope(scope) {
+		return validation.NewError("invalid_scope", "unknown scope {{.scope}}").
+			SetParams(map[string]any{"scope": scope})
+	}
+	return nil
+}
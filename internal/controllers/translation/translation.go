@@ -0,0 +1,110 @@
+package translation
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+type TranslationController struct {
+	controllers.Controller
+}
+
+func NewTranslationController(app *app.Registry) *TranslationController {
+	return &TranslationController{controllers.Controller{App: app}}
+}
+
+// GetTranslations lists the translations of a phone, tag or brand in every
+// locale.
+func (c *TranslationController) GetTranslations(w http.ResponseWriter, r *http.Request) {
+	entityType, entityID := c.entityFromURL(r)
+
+	translations, err := models.GetTranslations(c.App.DB, entityType, entityID)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, translations); err != nil {
+		panic(err)
+	}
+}
+
+func (c *TranslationController) UpdateTranslations(w http.ResponseWriter, r *http.Request) {
+	entityType, entityID := c.entityFromURL(r)
+
+	req := UpdateTranslationsRequest{EntityType: entityType, Locale: chi.URLParam(r, "Locale")}
+	if err := c.Validate(&req, r); err != nil {
+		panic(err)
+	}
+
+	tx := c.App.DB.MustBegin()
+	for field, value := range req.Fields {
+		var err error
+		if value == "" {
+			err = models.DeleteTranslations(tx, entityType, entityID, req.Locale, field)
+		} else {
+			t := models.Translation{EntityType: entityType, EntityID: entityID, Locale: req.Locale, Field: field, Value: value}
+			err = t.Save(tx)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			panic(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	translations, err := models.GetTranslations(c.App.DB, entityType, entityID)
+	if err != nil {
+		panic(err)
+	}
+	if err := responses.JSON(w, http.StatusOK, translations); err != nil {
+		panic(err)
+	}
+}
+
+func (c *TranslationController) DeleteTranslations(w http.ResponseWriter, r *http.Request) {
+	entityType, entityID := c.entityFromURL(r)
+
+	tx := c.App.DB.MustBegin()
+	if err := models.DeleteTranslations(tx, entityType, entityID, chi.URLParam(r, "Locale")); err != nil {
+		_ = tx.Rollback()
+		panic(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// entityFromURL reads the entity type and ID from the URL and checks that the
+// entity exists.
+func (c *TranslationController) entityFromURL(r *http.Request) (string, int) {
+	entityType := chi.URLParam(r, "EntityType")
+	id, err := strconv.Atoi(chi.URLParam(r, "EntityID"))
+	if err != nil {
+		panic(httperr.ErrNotFound)
+	}
+
+	switch entityType {
+	case models.TranslationPhone:
+		_, err = models.GetPhone(c.App.DB, id)
+	case models.TranslationTag:
+		_, err = models.GetTag(c.App.DB, id)
+	case models.TranslationBrand:
+		_, err = models.GetBrand(c.App.DB, id)
+	default:
+		panic(httperr.ErrNotFound)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return entityType, id
+}
@@ -0,0 +1,45 @@
+package translation
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+)
+
+// UpdateTranslationsRequest sets the translations of an entity in a locale.
+// A field set to an empty string loses its translation and falls back to the
+// stored value again.
+type UpdateTranslationsRequest struct {
+	EntityType string            `json:"-"`
+	Locale     string            `json:"-"`
+	Fields     map[string]string `json:"fields"`
+}
+
+func (r *UpdateTranslationsRequest) Authorized(ctx *reqdata.Context) bool {
+	return controllers.HasPermission(ctx, models.PermissionTranslationManage)
+}
+
+func (r *UpdateTranslationsRequest) Validate(ctx *reqdata.Context) error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Locale, validation.By(supportedLocale(ctx))),
+		validation.Field(&r.Fields, validation.Required, validation.By(func(value interface{}) error {
+			for field := range value.(map[string]string) {
+				if !models.IsTranslatable(r.EntityType, field) {
+					return validation.NewError("invalid_field", "{{.field}} cannot be translated on a {{.type}}").
+						SetParams(map[string]any{"field": field, "type": r.EntityType})
+				}
+			}
+			return nil
+		})),
+	)
+}
+
+func supportedLocale(ctx *reqdata.Context) validation.RuleFunc {
+	return func(value interface{}) error {
+		if !ctx.App.Localizer.Supports(value.(string)) {
+			return validation.NewError("invalid_locale", "locale is not supported")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+)
+
+const syncCampaignPricesLock = "hoki:sync_campaign_prices"
+
+// SyncCampaignPrices brings the campaign prices applied to phones in line with
+// the campaigns running now, recording every transition in the price history.
+// Instances sharing the database take turns through an advisory lock, so a
+// transition is never planned and recorded twice.
+func SyncCampaignPrices(a *app.Registry) func() error {
+	return func() error {
+		_, err := database.WithAdvisoryLock(a.DB, syncCampaignPricesLock, func() error {
+			return syncCampaignPrices(a)
+		})
+		if err != nil {
+			return fmt.Errorf("[SyncCampaignPrices]%w", err)
+		}
+		return nil
+	}
+}
+
+func syncCampaignPrices(a *app.Registry) error {
+	now := time.Now()
+
+	campaigns, err := models.GetActiveCampaigns(a.DB, now)
+	if err != nil {
+		return fmt.Errorf("[syncCampaignPrices]%w", err)
+	}
+	phones, err := models.GetCatalogPhones(a.DB)
+	if err != nil {
+		return fmt.Errorf("[syncCampaignPrices]%w", err)
+	}
+	current, err := models.GetPhoneCampaignPrices(a.DB)
+	if err != nil {
+		return fmt.Errorf("[syncCampaignPrices]%w", err)
+	}
+
+	transitions := models.PlanCampaignPrices(phones, campaigns, current, a.Config.Campaign.Resolution)
+	if len(transitions) == 0 {
+		return nil
+	}
+
+	tx := a.DB.MustBegin()
+	for _, t := range transitions {
+		if err := t.Save(tx, now); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("[syncCampaignPrices]%w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("[syncCampaignPrices][Commit]%w", err)
+	}
+	return nil
+}
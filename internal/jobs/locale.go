@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+// ReloadLocaleMessages rebuilds the localizer when the stored locale messages
+// changed, so edits made through another instance are picked up.
+func ReloadLocaleMessages(a *app.Registry) func() error {
+	loaded := ""
+	return func() error {
+		version, err := models.GetLocaleMessagesVersion(a.DB)
+		if err != nil {
+			return fmt.Errorf("[ReloadLocaleMessages]%w", err)
+		}
+		if version == loaded {
+			return nil
+		}
+
+		if err := ReloadLocalizer(a); err != nil {
+			return fmt.Errorf("[ReloadLocaleMessages]%w", err)
+		}
+		loaded = version
+		return nil
+	}
+}
+
+// ReloadLocalizer rebuilds the localizer from the locale files and the
+// stored locale messages.
+func ReloadLocalizer(a *app.Registry) error {
+	overrides, err := models.GetLocaleMessageOverrides(a.DB)
+	if err != nil {
+		return fmt.Errorf("[ReloadLocalizer]%w", err)
+	}
+	if err := a.Localizer.Reload(overrides); err != nil {
+		return fmt.Errorf("[ReloadLocalizer]%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+// ApplyScheduledPriceChanges applies the scheduled price changes and reverts
+// that are due. Each schedule runs in its own transaction so a failing one
+// does not hold back the others; it is retried on the next tick.
+func ApplyScheduledPriceChanges(a *app.Registry) func() error {
+	return func() error {
+		now := time.Now()
+
+		due, err := models.GetDueScheduledPriceChanges(a.DB, now)
+		if err != nil {
+			return fmt.Errorf("[ApplyScheduledPriceChanges]%w", err)
+		}
+		reverts, err := models.GetDueScheduledPriceReverts(a.DB, now)
+		if err != nil {
+			return fmt.Errorf("[ApplyScheduledPriceChanges]%w", err)
+		}
+
+		changed := false
+		for _, s := range due {
+			ok, err := runScheduledPriceChange(a, s.ID, models.ScheduledPriceChangePending, now)
+			if err != nil {
+				a.Log.Errorf("[ApplyScheduledPriceChanges] apply schedule %d: %v", s.ID, err)
+			}
+			changed = changed || ok
+		}
+		for _, s := range reverts {
+			ok, err := runScheduledPriceChange(a, s.ID, models.ScheduledPriceChangeApplied, now)
+			if err != nil {
+				a.Log.Errorf("[ApplyScheduledPriceChanges] revert schedule %d: %v", s.ID, err)
+			}
+			changed = changed || ok
+		}
+
+		if changed {
+			if err := a.Recommender.Refresh(a.DB); err != nil {
+				return fmt.Errorf("[ApplyScheduledPriceChanges]%w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// runScheduledPriceChange applies a pending schedule or reverts an applied
+// one. It does nothing when another worker already moved the schedule out of
+// the expected status.
+func runScheduledPriceChange(a *app.Registry, id int, status string, now time.Time) (bool, error) {
+	tx := a.DB.MustBegin()
+	s, err := models.LockScheduledPriceChange(tx, id)
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if s.Status != status {
+		_ = tx.Rollback()
+		return false, nil
+	}
+
+	rules, err := models.GetEnabledPricingRules(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if status == models.ScheduledPriceChangePending {
+		err = s.Apply(tx, now, rules)
+	} else {
+		err = s.Revert(tx, now, rules)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("[runScheduledPriceChange][Commit]%w", err)
+	}
+	return true, nil
+}
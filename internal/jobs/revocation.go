@@ -0,0 +1,18 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+)
+
+// ReloadRevocationList caches the tokens revoked outside of this instance,
+// through another instance or the CLI.
+func ReloadRevocationList(a *app.Registry) func() error {
+	return func() error {
+		if err := a.Auth.LoadRevocationList(); err != nil {
+			return fmt.Errorf("[ReloadRevocationList]%w", err)
+		}
+		return nil
+	}
+}
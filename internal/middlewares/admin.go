@@ -91,6 +91,8 @@ func AdminAuthMiddleware(app *app.Registry) func(http.Handler) http.Handler {
 			} else if err != nil {
 				panic(err)
 			}
+			verifyAdminToken(app, t)
+			touchAdminToken(app, t)
 
 			auth := AdminAuthInformation{
 				tokenID:     t.JwtID(),
@@ -105,3 +107,22 @@ func AdminAuthMiddleware(app *app.Registry) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// verifyAdminToken rejects the tokens of deactivated admins and the tokens
+// issued before the last security-relevant change of their admin.
+func verifyAdminToken(app *app.Registry, t jwt.Token) {
+	err := app.Auth.VerifyAdminToken(t)
+	if errors.Is(err, authentication.ErrAdminDeactivated) || errors.Is(err, authentication.ErrTokenInvalidated) {
+		panic(httperr.ErrUnauthenticated)
+	} else if err != nil {
+		panic(err)
+	}
+}
+
+// touchAdminToken records when the token was last used for the session list.
+// Failing to is not worth failing the request.
+func touchAdminToken(app *app.Registry, t jwt.Token) {
+	if err := app.Auth.TouchAdminToken(t.JwtID(), t.Expiration()); err != nil {
+		app.Log.Errorf("[touchAdminToken] %v", err)
+	}
+}
@@ -7,11 +7,11 @@ import (
 	"strings"
 	"time"
 
-	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
 	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/authentication"
 	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
 )
 
@@ -91,7 +91,7 @@ func AuthMiddleware(app *app.Registry) func(http.Handler) http.Handler {
 			opts := []jwt.ParseOption{
 				jwt.WithHeaderKey("Authorization"),
 				jwt.WithFormKey("x_access_token"),
-				jwt.WithKey(jwa.RS256, app.VerifyKey),
+				authentication.VerifyKeySet(app.VerifyKeys),
 			}
 
 			t, err := jwt.ParseRequest(r, opts...)
@@ -111,6 +111,10 @@ func AuthMiddleware(app *app.Registry) func(http.Handler) http.Handler {
 			if val, ok := act.(string); ok {
 				accountType = val
 			}
+			if accountType == models.AccountTypeAdmin {
+				verifyAdminToken(app, t)
+				touchAdminToken(app, t)
+			}
 
 			auth := AuthInformation{
 				tokenID:     t.JwtID(),
@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/responses"
+)
+
+// Locale negotiates the language of the request from its Accept-Language
+// header against the supported languages and stores it in the request
+// context, falling back to app.DefaultLanguage.
+func Locale(app *app.Registry) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			lang := app.Localizer.Negotiate(r.Header.Get("Accept-Language"))
+			w.Header().Set("Content-Language", lang)
+
+			ctx := context.WithValue(r.Context(), ContextLanguage, lang)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RequestLanguage returns the language negotiated by Locale, or
+// app.DefaultLanguage when the middleware did not run.
+func RequestLanguage(r *http.Request) string {
+	if lang, ok := r.Context().Value(ContextLanguage).(string); ok {
+		return lang
+	}
+	return app.DefaultLanguage
+}
+
+// translator localizes response messages in the language of the request.
+func translator(app *app.Registry, r *http.Request) responses.Translate {
+	lang := RequestLanguage(r)
+	return func(messageID string, templateData map[string]any) string {
+		return app.Localizer.GetLocalizedLanguage(lang, messageID, templateData)
+	}
+}
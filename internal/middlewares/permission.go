@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/permission"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/reqdata"
+)
+
+// RequirePermission only lets through admins whose role holds the
+// permission, and systems whose token holds a scope standing for it. It has
+// to run after AuthMiddleware or AdminAuthMiddleware.
+func RequirePermission(app *app.Registry, identifier string) func(http.Handler) http.Handler {
+	if !models.IsRegisteredPermission(identifier) {
+		panic("middlewares: permission " + identifier + " is not in models.PermissionRegistry")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := requestAuth(r)
+			switch auth.AccountType() {
+			case models.AccountTypeAdmin:
+				user, err := auth.User()
+				if err != nil {
+					panic(err)
+				}
+				admin, ok := user.(*models.Admin)
+				if !ok {
+					panic(httperr.ErrForbidden)
+				}
+
+				allowed, err := permission.AdminHas(app.Cache, app.DB, admin, identifier)
+				if err != nil {
+					panic(err)
+				} else if !allowed {
+					panic(httperr.ErrForbidden)
+				}
+			case models.AccountTypeSystem:
+				if !models.ScopesGrantPermission(TokenScopes(auth.Token()), identifier) {
+					panic(httperr.ErrForbidden)
+				}
+			default:
+				panic(httperr.ErrForbidden)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope only lets systems through when their token holds the scope,
+// admins are left to their permissions. It has to run after AuthMiddleware.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	if !models.IsSystemScope(scope) {
+		panic("middlewares: scope " + scope + " is not in models.SystemScopes")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := requestAuth(r)
+			switch auth.AccountType() {
+			case models.AccountTypeAdmin:
+			case models.AccountTypeSystem:
+				if !hasScope(TokenScopes(auth.Token()), scope) {
+					panic(httperr.ErrForbidden)
+				}
+			default:
+				panic(httperr.ErrForbidden)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TokenScopes returns the scopes of a token, none for tokens issued without
+// a scope claim.
+func TokenScopes(t jwt.Token) []string {
+	if t == nil {
+		return nil
+	}
+	claim, _ := t.Get("scope")
+	scope, _ := claim.(string)
+	return strings.Fields(scope)
+}
+
+func requestAuth(r *http.Request) reqdata.AuthInformation {
+	auth, ok := r.Context().Value(ContextAuth).(reqdata.AuthInformation)
+	if !ok || !auth.IsLoggedIn() {
+		panic(httperr.ErrUnauthenticated)
+	}
+	return auth
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
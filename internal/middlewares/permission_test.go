@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+func TestSystemScopes(t *testing.T) {
+	token, err := jwt.NewBuilder().Subject("systems:1").Claim("scope", "catalog:read prices:read").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := &AuthInformation{userID: "systems:1", accountType: models.AccountTypeSystem, token: token}
+
+	tests := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+		allowed    bool
+	}{
+		{"granted scope", RequireScope(models.SystemScopeCatalogRead), true},
+		{"missing scope", RequireScope(models.SystemScopeCatalogWrite), false},
+		{"permission without scope", RequirePermission(nil, models.PermissionPhoneUpdate), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := tt.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/phones", nil)
+			r = r.WithContext(context.WithValue(r.Context(), ContextAuth, auth))
+			defer func() {
+				recovered := recover()
+				if tt.allowed && (recovered != nil || !called) {
+					t.Errorf("want the request let through; got %v", recovered)
+				} else if !tt.allowed && recovered != httperr.ErrForbidden {
+					t.Errorf("want %v; got %v", httperr.ErrForbidden, recovered)
+				}
+			}()
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+		})
+	}
+}
+
+func TestScopesGrantPermission(t *testing.T) {
+	if !models.ScopesGrantPermission([]string{models.SystemScopeCatalogWrite}, models.PermissionPhoneUpdate) {
+		t.Error("want catalog:write to grant phone updates")
+	}
+	if models.ScopesGrantPermission([]string{models.SystemScopeCatalogRead, models.SystemScopePricesRead}, models.PermissionPhoneUpdate) {
+		t.Error("want read scopes not to grant phone updates")
+	}
+}
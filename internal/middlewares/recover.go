@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
 	"os"
 	"runtime/debug"
 	"strings"
@@ -17,6 +16,7 @@ import (
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/ratelimiter"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/go-chi/chi/v5/middleware"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
 	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
@@ -30,61 +30,62 @@ func Recover(app *app.Registry) func(handler http.Handler) http.Handler {
 			defer func() {
 				if rec := recover(); rec != nil && rec != http.ErrAbortHandler {
 					details := rec
+					t := translator(app, r)
 					if err, ok := rec.(error); ok {
 						if errors.Is(err, context.Canceled) {
 							return
 						}
 
 						if errors.Is(err, httperr.ErrUnauthenticated) {
-							responses.Unauthenticated(w)
+							responses.Unauthenticated(w, r, t)
 							return
 						}
 
 						if errors.Is(err, httperr.ErrForbidden) {
-							responses.Forbidden(w)
+							responses.Forbidden(w, r, t)
 							return
 						}
 
 						if errors.Is(err, httperr.ErrNotFound) || errors.Is(err, sql.ErrNoRows) || errors.Is(err, filestore.ErrFileNotExist) {
-							responses.NotFound(w, nil)
+							responses.NotFound(w, r, t)
 							return
 						}
 
 						if err, ok := err.(validation.Errors); ok {
-							responses.ValidationError(w, err)
+							responses.ValidationError(w, r, t, err)
 							return
 						}
 
 						if errors.Is(err, httperr.ErrMalformedRequest) {
-							responses.MalformedRequest(w)
+							responses.MalformedRequest(w, r, t)
 							return
 						}
 
 						if err, ok := err.(httperr.ErrUnprocessableEntity); ok {
-							responses.UnprocessableEntity(w, err)
+							responses.UnprocessableEntity(w, r, t, err)
 							return
 						}
 
 						if errors.Is(err, httperr.ErrServiceUnavailable) {
-							responses.ServiceUnavailable(w)
+							responses.ServiceUnavailable(w, r, t)
 							return
 						}
 
 						if errors.Is(err, httperr.ErrTooManyRequests) || errors.Is(err, ratelimiter.ErrRateLimited) {
-							responses.TooManyRequests(w)
+							responses.TooManyRequests(w, r, t)
 							return
 						}
 
 						details = err.Error()
 					}
 
-					app.Log.Error(fmt.Sprint(details))
+					app.Log.Errorf("[%s] %v", middleware.GetReqID(r.Context()), details)
 
 					if app.Config.Debug {
 						printStackTrace(rec)
-						responses.InternalServerError(w, details)
+						responses.InternalServerError(w, r, t, details)
 					} else {
-						responses.InternalServerError(w, nil)
+						responses.InternalServerError(w, r, t, nil)
 					}
 				}
 			}()
@@ -202,10 +203,6 @@ func styleFunctionCall(call string) string {
 
 func NotFound(app *app.Registry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var u *url.URL
-		if app.Config.Debug {
-			u = r.URL
-		}
-		responses.NotFound(w, u)
+		responses.NotFound(w, r, translator(app, r))
 	}
 }
@@ -3,5 +3,6 @@ package middlewares
 type contextKey string
 
 const (
-	ContextAuth contextKey = "Auth"
+	ContextAuth     contextKey = "Auth"
+	ContextLanguage contextKey = "Language"
 )
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+// TagAccessories is the seeded tag marking a row of the phones table as an
+// accessory such as a case or a charger.
+const TagAccessories = "accessories"
+
+// PhoneSummary is the short form of a phone used when phones are listed as a
+// part of another phone.
+type PhoneSummary struct {
+	ID        int          `db:"id" json:"id"`
+	Name      string       `db:"name" json:"name"`
+	BrandID   int          `db:"brand_id" json:"brand_id"`
+	BrandName string       `db:"brand_name" json:"brand_name"`
+	Price     money.Amount `db:"price" json:"price"`
+}
+
+func (p *Phone) IsAccessory() bool {
+	for _, tag := range p.Tags {
+		if tag.Name == TagAccessories {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadCompatibility fills CompatibleDevices for accessories and
+// CompatibleAccessories for every other phone.
+func (p *Phone) LoadCompatibility(db database.Queryer) error {
+	var err error
+	if p.IsAccessory() {
+		p.CompatibleDevices, err = GetCompatibleDevices(db, p.ID)
+	} else {
+		p.CompatibleAccessories, err = GetCompatibleAccessories(db, p.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("[Phone.LoadCompatibility]%w", err)
+	}
+	return nil
+}
+
+const phoneSummaryColumns = `phones.id, phones.name, phones.brand_id, brands.name AS brand_name, phones.price`
+
+func GetCompatibleAccessories(db database.Queryer, phoneID int) ([]PhoneSummary, error) {
+	accessories := []PhoneSummary{}
+	query := `
+    SELECT ` + phoneSummaryColumns + `
+    FROM accessory_compatibilities ac
+    JOIN phones ON ac.accessory_id = phones.id
+    JOIN brands ON phones.brand_id = brands.id
+    WHERE ac.phone_id = ? AND phones.deleted_at IS NULL
+    ORDER BY phones.name
+  `
+	err := db.Select(&accessories, query, phoneID)
+	if err != nil {
+		return nil, fmt.Errorf("[GetCompatibleAccessories][Select]%w", err)
+	}
+	return accessories, nil
+}
+
+func GetCompatibleDevices(db database.Queryer, accessoryID int) ([]PhoneSummary, error) {
+	devices := []PhoneSummary{}
+	query := `
+    SELECT ` + phoneSummaryColumns + `
+    FROM accessory_compatibilities ac
+    JOIN phones ON ac.phone_id = phones.id
+    JOIN brands ON phones.brand_id = brands.id
+    WHERE ac.accessory_id = ? AND phones.deleted_at IS NULL
+    ORDER BY phones.name
+  `
+	err := db.Select(&devices, query, accessoryID)
+	if err != nil {
+		return nil, fmt.Errorf("[GetCompatibleDevices][Select]%w", err)
+	}
+	return devices, nil
+}
+
+func ReplaceCompatibleAccessories(tx database.TxQueryer, phoneID int, accessoryIDs []int) error {
+	_, err := tx.Exec("DELETE FROM accessory_compatibilities WHERE phone_id = ?", phoneID)
+	if err != nil {
+		return fmt.Errorf("[ReplaceCompatibleAccessories][Delete]%w", err)
+	}
+	for _, accessoryID := range accessoryIDs {
+		if err := AddAccessoryCompatibility(tx, accessoryID, phoneID); err != nil {
+			return fmt.Errorf("[ReplaceCompatibleAccessories]%w", err)
+		}
+	}
+	return nil
+}
+
+func ReplaceCompatibleDevices(tx database.TxQueryer, accessoryID int, phoneIDs []int) error {
+	_, err := tx.Exec("DELETE FROM accessory_compatibilities WHERE accessory_id = ?", accessoryID)
+	if err != nil {
+		return fmt.Errorf("[ReplaceCompatibleDevices][Delete]%w", err)
+	}
+	for _, phoneID := range phoneIDs {
+		if err := AddAccessoryCompatibility(tx, accessoryID, phoneID); err != nil {
+			return fmt.Errorf("[ReplaceCompatibleDevices]%w", err)
+		}
+	}
+	return nil
+}
+
+func AddAccessoryCompatibility(tx database.TxQueryer, accessoryID int, phoneID int) error {
+	_, err := tx.Exec("INSERT IGNORE INTO accessory_compatibilities (accessory_id, phone_id) VALUES (?, ?)", accessoryID, phoneID)
+	if err != nil {
+		return fmt.Errorf("[AddAccessoryCompatibility][Exec]%w", err)
+	}
+	return nil
+}
+
+// CopyAccessoryCompatibility makes every accessory that fits fromPhoneID also
+// fit toPhoneID, keeping the accessories toPhoneID already had. It returns the
+// number of newly linked accessories.
+func CopyAccessoryCompatibility(tx database.TxQueryer, fromPhoneID int, toPhoneID int) (int64, error) {
+	query := `
+    INSERT IGNORE INTO accessory_compatibilities (accessory_id, phone_id)
+    SELECT accessory_id, ? FROM accessory_compatibilities WHERE phone_id = ?
+  `
+	res, err := tx.Exec(query, toPhoneID, fromPhoneID)
+	if err != nil {
+		return 0, fmt.Errorf("[CopyAccessoryCompatibility][Exec]%w", err)
+	}
+	affected, _ := res.RowsAffected()
+	return affected, nil
+}
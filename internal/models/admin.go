@@ -22,6 +22,11 @@ type Admin struct {
 	Role          *Role       `json:"role,omitempty" mapstructure:"role"`
 	ProviderID    string      `json:"provider_id" db:"provider_id" mapstructure:"provider_id"`
 	DeactivatedAt null.Time   `json:"deactivated_at" db:"deactivated_at" mapstructure:"deactivated_at"`
+	// TokensValidAfter rejects the tokens issued before it or within the same
+	// second, in unix seconds. Tokens only tell the second they were issued,
+	// so those issued the second of a change cannot be told apart.
+	// It is moved forward on every security-relevant change of the admin.
+	TokensValidAfter null.Int `json:"tokens_valid_after" db:"tokens_valid_after" mapstructure:"tokens_valid_after"`
 }
 
 func (a *Admin) Insert(db database.Queryer) error {
@@ -47,6 +52,7 @@ func (a *Admin) Update(db database.Queryer) error {
 			name = :name,
 			username = :username,
 			role_id = :role_id,
+			tokens_valid_after = :tokens_valid_after,
 			updated_at = :updated_at,
 			deactivated_at = :deactivated_at
 		WHERE id = :id
@@ -59,8 +65,23 @@ func (a *Admin) Update(db database.Queryer) error {
 	return nil
 }
 
+// IsDeactivated reports whether the admin was deactivated, deactivated
+// admins cannot log in.
+func (a *Admin) IsDeactivated() bool {
+	return a.DeactivatedAt.Valid
+}
+
+// InvalidateTokens rejects every token issued to the admin so far, as a
+// forced logout.
+func (a *Admin) InvalidateTokens() {
+	a.TokensValidAfter = null.IntFrom(time.Now().Unix())
+}
+
+// ChangeRole moves the admin to another role, the tokens issued for the old
+// role stop working.
 func (a *Admin) ChangeRole(db database.Queryer, r *Role) error {
 	a.RoleID = null.StringFrom(r.ID)
+	a.InvalidateTokens()
 	err := a.Update(db)
 	if err != nil {
 		return fmt.Errorf("[a.ChangeRole]%w", err)
@@ -115,9 +136,10 @@ func (a *Admin) IssueAccessToken(exp time.Time) (jwt.Token, error) {
 
 type AdminAccessToken struct {
 	Model
-	AdminID   string    `json:"admin_id" db:"admin_id"`
-	RevokedAt null.Time `json:"revoked_at" db:"revoked_at"`
-	ExpiredAt null.Time `json:"expired_at" db:"expired_at"`
+	AdminID            string             `json:"admin_id" db:"admin_id"`
+	RevokedAt          null.Time          `json:"revoked_at" db:"revoked_at"`
+	ExpiredAt          null.Time          `json:"expired_at" db:"expired_at"`
+	RequestFingerprint RequestFingerprint `json:"request_fingerprint" db:"request_fingerprint"`
 }
 
 func (aat *AdminAccessToken) Insert(db database.Queryer) error {
@@ -125,8 +147,8 @@ func (aat *AdminAccessToken) Insert(db database.Queryer) error {
 	aat.Model.CreatedAt = now
 	aat.Model.UpdatedAt = now
 
-	q := "INSERT INTO admin_access_tokens (id,admin_id,expired_at,revoked_at,created_at,updated_at) " +
-		"VALUES (:id,:admin_id,:expired_at,:revoked_at,:created_at,:updated_at)"
+	q := "INSERT INTO admin_access_tokens (id,admin_id,expired_at,revoked_at,request_fingerprint,created_at,updated_at) " +
+		"VALUES (:id,:admin_id,:expired_at,:revoked_at,:request_fingerprint,:created_at,:updated_at)"
 	_, err := db.NamedExec(q, aat)
 	if err != nil {
 		return fmt.Errorf("[aat.Insert][NamedExec]%w", err)
@@ -137,7 +159,7 @@ func (aat *AdminAccessToken) Insert(db database.Queryer) error {
 func (aat *AdminAccessToken) Update(db database.Queryer) error {
 	aat.Model.UpdatedAt = time.Now().Unix()
 
-	q := "UPDATE admin_access_tokens" +
+	q := "UPDATE admin_access_tokens " +
 		"SET admin_id = :admin_id," +
 		"expired_at = :expired_at," +
 		"revoked_at = :revoked_at," +
@@ -163,6 +185,50 @@ func GetAdminAccessTokenByID(db database.Queryer, id string) (*AdminAccessToken,
 	return &mat, true, nil
 }
 
+// GetActiveAdminAccessTokens returns the tokens of an admin that are neither
+// revoked nor expired.
+func GetActiveAdminAccessTokens(db database.Queryer, adminID string) ([]AdminAccessToken, error) {
+	var tokens []AdminAccessToken
+	err := db.Select(
+		&tokens,
+		"SELECT * FROM admin_access_tokens WHERE admin_id = ? AND revoked_at IS NULL AND (expired_at IS NULL OR expired_at > NOW())",
+		adminID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("[GetActiveAdminAccessTokens][Select]%w", err)
+	}
+	return tokens, nil
+}
+
+// AdminSessionToken is an access token of an admin along with the refresh
+// token family it was issued in, none for the tokens issued without a
+// refresh token.
+type AdminSessionToken struct {
+	AdminAccessToken
+	FamilyID null.String `json:"family_id" db:"family_id"`
+}
+
+// GetAdminSessionTokens returns the tokens of an admin that still keep a
+// session alive, newest first: the active ones and the expired ones whose
+// refresh token can still be traded for a new one.
+func GetAdminSessionTokens(db database.Queryer, adminID string) ([]AdminSessionToken, error) {
+	var tokens []AdminSessionToken
+	err := db.Select(
+		&tokens,
+		"SELECT admin_access_tokens.*, admin_refresh_tokens.family_id FROM admin_access_tokens "+
+			"LEFT JOIN admin_refresh_tokens ON admin_refresh_tokens.access_token_id = admin_access_tokens.id "+
+			"WHERE admin_access_tokens.admin_id = ? AND admin_access_tokens.revoked_at IS NULL AND ("+
+			"admin_access_tokens.expired_at IS NULL OR admin_access_tokens.expired_at > NOW() OR ("+
+			"admin_refresh_tokens.used_at IS NULL AND admin_refresh_tokens.revoked_at IS NULL AND admin_refresh_tokens.expires_at > UNIX_TIMESTAMP()"+
+			")) ORDER BY admin_access_tokens.created_at DESC, admin_access_tokens.id DESC",
+		adminID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("[GetAdminSessionTokens][Select]%w", err)
+	}
+	return tokens, nil
+}
+
 func GetAdminBatched(db database.Queryer, lastID string, lastCreatedAt int64, limit int) ([]Admin, error) {
 	q := `
 	SELECT * FROM admins 
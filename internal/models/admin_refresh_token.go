@@ -0,0 +1,143 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/random"
+	"gopkg.in/guregu/null.v4"
+)
+
+// AdminRefreshToken trades for a new access token and refresh token once.
+// The tokens descending from a login share a family, which is revoked as a
+// whole when one of its used tokens shows up again.
+type AdminRefreshToken struct {
+	Model
+	AdminID       string   `json:"admin_id" db:"admin_id"`
+	FamilyID      string   `json:"family_id" db:"family_id"`
+	TokenHash     string   `json:"-" db:"token_hash"`
+	AccessTokenID string   `json:"access_token_id" db:"access_token_id"`
+	ExpiresAt     int64    `json:"expires_at" db:"expires_at"`
+	UsedAt        null.Int `json:"used_at" db:"used_at"`
+	RevokedAt     null.Int `json:"revoked_at" db:"revoked_at"`
+}
+
+// NewAdminRefreshToken makes a refresh token paired with an access token,
+// starting a new family when familyID is empty. The plain token is returned
+// alongside, only its hash is stored.
+func NewAdminRefreshToken(adminID string, familyID string, accessTokenID string, ttl time.Duration) (AdminRefreshToken, string) {
+	plain := random.GenerateString(64, random.UppercaseAlphabeticCharset+random.LowercaseAlphabeticCharset+random.NumericCharset)
+	t := AdminRefreshToken{
+		AdminID:       adminID,
+		FamilyID:      familyID,
+		TokenHash:     HashSecretKey(plain),
+		AccessTokenID: accessTokenID,
+		ExpiresAt:     time.Now().Add(ttl).Unix(),
+	}
+	return t, plain
+}
+
+func (t *AdminRefreshToken) Insert(db database.TxQueryer) error {
+	t.BeforeInsert("admin_refresh_tokens")
+	if t.FamilyID == "" {
+		t.FamilyID = t.ID
+	}
+
+	q := `
+		INSERT INTO admin_refresh_tokens
+		(id, admin_id, family_id, token_hash, access_token_id, expires_at, used_at, revoked_at, created_at, updated_at)
+		VALUES
+		(:id, :admin_id, :family_id, :token_hash, :access_token_id, :expires_at, :used_at, :revoked_at, :created_at, :updated_at)
+	`
+	if _, err := db.NamedExec(q, t); err != nil {
+		return fmt.Errorf("[t.Insert][NamedExec]%w", err)
+	}
+	return nil
+}
+
+// MarkUsed consumes the refresh token, false when it was consumed already,
+// by a concurrent refresh for example.
+func (t *AdminRefreshToken) MarkUsed(db database.TxQueryer) (bool, error) {
+	now := time.Now().Unix()
+	res, err := db.Exec(
+		"UPDATE admin_refresh_tokens SET used_at = ?, updated_at = ? WHERE id = ? AND used_at IS NULL",
+		now, now, t.ID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("[t.MarkUsed][Exec]%w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("[t.MarkUsed][RowsAffected]%w", err)
+	}
+	if affected == 1 {
+		t.UsedAt = null.IntFrom(now)
+	}
+	return affected == 1, nil
+}
+
+func (t *AdminRefreshToken) IsExpired() bool {
+	return time.Now().Unix() >= t.ExpiresAt
+}
+
+func GetAdminRefreshTokenByPlain(db database.Queryer, plain string) (*AdminRefreshToken, bool, error) {
+	var t AdminRefreshToken
+	err := db.Get(&t, "SELECT * FROM admin_refresh_tokens WHERE token_hash = ?", HashSecretKey(plain))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("[GetAdminRefreshTokenByPlain][Get]%w", err)
+	}
+	return &t, true, nil
+}
+
+func GetAdminRefreshTokenByAccessTokenID(db database.Queryer, accessTokenID string) (*AdminRefreshToken, bool, error) {
+	var t AdminRefreshToken
+	err := db.Get(&t, "SELECT * FROM admin_refresh_tokens WHERE access_token_id = ?", accessTokenID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("[GetAdminRefreshTokenByAccessTokenID][Get]%w", err)
+	}
+	return &t, true, nil
+}
+
+// GetAdminRefreshTokenFamilyAccessTokenIDs returns the access tokens issued
+// in a family.
+func GetAdminRefreshTokenFamilyAccessTokenIDs(db database.Queryer, familyID string) ([]string, error) {
+	ids := []string{}
+	err := db.Select(&ids, "SELECT access_token_id FROM admin_refresh_tokens WHERE family_id = ?", familyID)
+	if err != nil {
+		return nil, fmt.Errorf("[GetAdminRefreshTokenFamilyAccessTokenIDs][Select]%w", err)
+	}
+	return ids, nil
+}
+
+// RevokeAdminRefreshTokenFamily revokes every refresh token of a family.
+func RevokeAdminRefreshTokenFamily(db database.TxQueryer, familyID string) error {
+	now := time.Now().Unix()
+	_, err := db.Exec(
+		"UPDATE admin_refresh_tokens SET revoked_at = ?, updated_at = ? WHERE family_id = ? AND revoked_at IS NULL",
+		now, now, familyID,
+	)
+	if err != nil {
+		return fmt.Errorf("[RevokeAdminRefreshTokenFamily][Exec]%w", err)
+	}
+	return nil
+}
+
+// RevokeAdminRefreshTokens revokes every refresh token of an admin.
+func RevokeAdminRefreshTokens(db database.TxQueryer, adminID string) error {
+	now := time.Now().Unix()
+	_, err := db.Exec(
+		"UPDATE admin_refresh_tokens SET revoked_at = ?, updated_at = ? WHERE admin_id = ? AND revoked_at IS NULL",
+		now, now, adminID,
+	)
+	if err != nil {
+		return fmt.Errorf("[RevokeAdminRefreshTokens][Exec]%w", err)
+	}
+	return nil
+}
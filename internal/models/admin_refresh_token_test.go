@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewAdminRefreshToken(t *testing.T) {
+	token, plain := NewAdminRefreshToken("admins:1", "", "admin_access_tokens:1", time.Hour)
+
+	if token.TokenHash == plain {
+		t.Error("want the plain token not stored")
+	}
+	if token.TokenHash != HashSecretKey(plain) {
+		t.Error("want the hash of the plain token stored")
+	}
+	if token.IsExpired() {
+		t.Error("want a fresh token not expired")
+	}
+
+	other, otherPlain := NewAdminRefreshToken("admins:1", "", "admin_access_tokens:2", time.Hour)
+	if otherPlain == plain || other.TokenHash == token.TokenHash {
+		t.Error("want every token different")
+	}
+
+	expired, _ := NewAdminRefreshToken("admins:1", "", "admin_access_tokens:3", -time.Second)
+	if !expired.IsExpired() {
+		t.Error("want a token past its lifetime expired")
+	}
+}
@@ -0,0 +1,464 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+const (
+	CampaignRulePercentOff = "percent_off"
+	CampaignRuleAmountOff  = "amount_off"
+	CampaignRuleFixedPrice = "fixed_price"
+)
+
+const (
+	CampaignTargetPhone = "phone"
+	CampaignTargetBrand = "brand"
+	CampaignTargetTag   = "tag"
+)
+
+const (
+	CampaignResolutionPriority  = "priority"
+	CampaignResolutionBestPrice = "best_price"
+)
+
+type Campaign struct {
+	ID        int              `db:"id" json:"id"`
+	Name      string           `db:"name" json:"name"`
+	RuleType  string           `db:"rule_type" json:"rule_type"`
+	RuleValue money.Amount     `db:"rule_value" json:"rule_value"`
+	Priority  int              `db:"priority" json:"priority"`
+	StartsAt  time.Time        `db:"starts_at" json:"starts_at"`
+	EndsAt    time.Time        `db:"ends_at" json:"ends_at"`
+	CreatedAt time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time        `db:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time       `db:"deleted_at" json:"deleted_at"`
+	Targets   []CampaignTarget `json:"targets"`
+}
+
+type CampaignTarget struct {
+	ID         int    `db:"id" json:"id"`
+	CampaignID int    `db:"campaign_id" json:"campaign_id"`
+	TargetType string `db:"target_type" json:"target_type"`
+	TargetID   int    `db:"target_id" json:"target_id"`
+}
+
+// CampaignSummary is the part of a campaign shown on phone responses.
+type CampaignSummary struct {
+	ID        int          `db:"id" json:"id"`
+	Name      string       `db:"name" json:"name"`
+	RuleType  string       `db:"rule_type" json:"rule_type"`
+	RuleValue money.Amount `db:"rule_value" json:"rule_value"`
+	EndsAt    time.Time    `db:"ends_at" json:"ends_at"`
+}
+
+func (c *Campaign) Insert(tx database.TxQueryer) error {
+	query := `
+    INSERT INTO campaigns (name, rule_type, rule_value, priority, starts_at, ends_at)
+    VALUES (:name, :rule_type, :rule_value, :priority, :starts_at, :ends_at);
+  `
+	_, err := tx.NamedExec(query, c)
+	if err != nil {
+		return fmt.Errorf("[Campaign.Insert][NamedExec]%w", err)
+	}
+	err = tx.QueryRow("SELECT LAST_INSERT_ID()").Scan(&c.ID)
+	if err != nil {
+		return fmt.Errorf("[Campaign.Insert][QueryRow]%w", err)
+	}
+	if err := c.insertTargets(tx); err != nil {
+		return fmt.Errorf("[Campaign.Insert]%w", err)
+	}
+	return nil
+}
+
+func (c *Campaign) Update(tx database.TxQueryer) error {
+	query := `
+    UPDATE campaigns SET name = :name, rule_type = :rule_type, rule_value = :rule_value, priority = :priority, starts_at = :starts_at, ends_at = :ends_at, updated_at = CURRENT_TIMESTAMP
+    WHERE id = :id;
+  `
+	_, err := tx.NamedExec(query, c)
+	if err != nil {
+		return fmt.Errorf("[Campaign.Update][NamedExec]%w", err)
+	}
+	_, err = tx.Exec("DELETE FROM campaign_targets WHERE campaign_id = ?", c.ID)
+	if err != nil {
+		return fmt.Errorf("[Campaign.Update][DeleteTargets]%w", err)
+	}
+	if err := c.insertTargets(tx); err != nil {
+		return fmt.Errorf("[Campaign.Update]%w", err)
+	}
+	return nil
+}
+
+func (c *Campaign) Delete(tx database.TxQueryer) error {
+	_, err := tx.Exec("UPDATE campaigns SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?", c.ID)
+	if err != nil {
+		return fmt.Errorf("[Campaign.Delete][Exec]%w", err)
+	}
+	return nil
+}
+
+func (c *Campaign) insertTargets(tx database.TxQueryer) error {
+	for i := range c.Targets {
+		c.Targets[i].CampaignID = c.ID
+		_, err := tx.NamedExec(`
+      INSERT INTO campaign_targets (campaign_id, target_type, target_id)
+      VALUES (:campaign_id, :target_type, :target_id);
+    `, c.Targets[i])
+		if err != nil {
+			return fmt.Errorf("[Campaign.insertTargets][NamedExec]%w", err)
+		}
+	}
+	return nil
+}
+
+// AppliesTo reports whether any target of the campaign matches the phone,
+// either directly or through its brand or one of its tags.
+func (c *Campaign) AppliesTo(phone Phone) bool {
+	for _, t := range c.Targets {
+		switch t.TargetType {
+		case CampaignTargetPhone:
+			if t.TargetID == phone.ID {
+				return true
+			}
+		case CampaignTargetBrand:
+			if t.TargetID == phone.BrandID {
+				return true
+			}
+		case CampaignTargetTag:
+			for _, tag := range phone.Tags {
+				if tag.ID == t.TargetID {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Apply returns the campaign price for a base price, percentages are rounded
+// half up to the cent. The result never goes below zero nor above the base
+// price.
+func (c *Campaign) Apply(price money.Amount) money.Amount {
+	result := price
+	switch c.RuleType {
+	case CampaignRulePercentOff:
+		result = price.Percent(100-c.RuleValue.Float64(), money.HalfUp)
+	case CampaignRuleAmountOff:
+		result = price - c.RuleValue
+	case CampaignRuleFixedPrice:
+		result = c.RuleValue
+	}
+	return money.Max(0, money.Min(price, result))
+}
+
+// CampaignPriceJump is a phone price a campaign would move past the price
+// approval threshold.
+type CampaignPriceJump struct {
+	PhoneID       int          `json:"phone_id"`
+	Price         money.Amount `json:"price"`
+	CampaignPrice money.Amount `json:"campaign_price"`
+}
+
+// PriceJumps returns the phones whose price the campaign would move past the
+// approval threshold. Percent rules state the size of the cut outright, it is
+// amounts and fixed prices where a mistyped digit goes unnoticed.
+func (c *Campaign) PriceJumps(phones []Phone, thresholdPercent float64) []CampaignPriceJump {
+	jumps := []CampaignPriceJump{}
+	if c.RuleType == CampaignRulePercentOff {
+		return jumps
+	}
+	for _, phone := range phones {
+		if !c.AppliesTo(phone) {
+			continue
+		}
+		price := c.Apply(phone.Price)
+		if RequiresPriceApproval(phone.Price, price, thresholdPercent) {
+			jumps = append(jumps, CampaignPriceJump{PhoneID: phone.ID, Price: phone.Price, CampaignPrice: price})
+		}
+	}
+	return jumps
+}
+
+// ResolveCampaign picks the campaign setting the price of a phone among the
+// active campaigns. With CampaignResolutionBestPrice the lowest resulting
+// price wins, otherwise the highest priority does. Remaining ties go to the
+// campaign created first.
+func ResolveCampaign(phone Phone, campaigns []Campaign, resolution string) (*Campaign, money.Amount) {
+	var winner *Campaign
+	price := phone.Price
+	for i := range campaigns {
+		c := &campaigns[i]
+		if !c.AppliesTo(phone) {
+			continue
+		}
+		candidate := c.Apply(phone.Price)
+		if winner == nil || beats(c, candidate, winner, price, resolution) {
+			winner = c
+			price = candidate
+		}
+	}
+	return winner, price
+}
+
+func beats(c *Campaign, price money.Amount, winner *Campaign, winnerPrice money.Amount, resolution string) bool {
+	if resolution == CampaignResolutionBestPrice {
+		if price != winnerPrice {
+			return price < winnerPrice
+		}
+	} else if c.Priority != winner.Priority {
+		return c.Priority > winner.Priority
+	} else if price != winnerPrice {
+		return price < winnerPrice
+	}
+	return c.ID < winner.ID
+}
+
+func GetCampaign(db database.Queryer, id int) (*Campaign, bool, error) {
+	var c Campaign
+	err := db.Get(&c, "SELECT * FROM campaigns WHERE id = ? AND deleted_at IS NULL", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("[GetCampaign][Get]%w", err)
+	}
+
+	campaigns := []Campaign{c}
+	if err := loadCampaignTargets(db, campaigns); err != nil {
+		return nil, false, fmt.Errorf("[GetCampaign]%w", err)
+	}
+	return &campaigns[0], true, nil
+}
+
+func GetCampaigns(db database.Queryer) ([]Campaign, error) {
+	campaigns := []Campaign{}
+	err := db.Select(&campaigns, "SELECT * FROM campaigns WHERE deleted_at IS NULL ORDER BY starts_at DESC, id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("[GetCampaigns][Select]%w", err)
+	}
+	if err := loadCampaignTargets(db, campaigns); err != nil {
+		return nil, fmt.Errorf("[GetCampaigns]%w", err)
+	}
+	return campaigns, nil
+}
+
+func GetActiveCampaigns(db database.Queryer, now time.Time) ([]Campaign, error) {
+	campaigns := []Campaign{}
+	query := `
+    SELECT * FROM campaigns
+    WHERE deleted_at IS NULL AND starts_at <= ? AND ends_at > ?
+    ORDER BY id
+  `
+	err := db.Select(&campaigns, query, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("[GetActiveCampaigns][Select]%w", err)
+	}
+	if err := loadCampaignTargets(db, campaigns); err != nil {
+		return nil, fmt.Errorf("[GetActiveCampaigns]%w", err)
+	}
+	return campaigns, nil
+}
+
+func loadCampaignTargets(db database.Queryer, campaigns []Campaign) error {
+	if len(campaigns) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(campaigns))
+	for _, c := range campaigns {
+		ids = append(ids, c.ID)
+	}
+	query, args, err := sqlx.In("SELECT * FROM campaign_targets WHERE campaign_id IN (?) ORDER BY id", ids)
+	if err != nil {
+		return fmt.Errorf("[loadCampaignTargets][In]%w", err)
+	}
+
+	var targets []CampaignTarget
+	if err := db.Select(&targets, db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("[loadCampaignTargets][Select]%w", err)
+	}
+
+	byCampaign := map[int][]CampaignTarget{}
+	for _, t := range targets {
+		byCampaign[t.CampaignID] = append(byCampaign[t.CampaignID], t)
+	}
+	for i := range campaigns {
+		campaigns[i].Targets = byCampaign[campaigns[i].ID]
+		if campaigns[i].Targets == nil {
+			campaigns[i].Targets = []CampaignTarget{}
+		}
+	}
+	return nil
+}
+
+// PhoneCampaignPrice is the campaign price currently applied to a phone. The
+// table is maintained by SyncCampaignPrices so that every transition is
+// recorded in the price history.
+type PhoneCampaignPrice struct {
+	PhoneID    int          `db:"phone_id"`
+	CampaignID int          `db:"campaign_id"`
+	Price      money.Amount `db:"price"`
+}
+
+// CampaignPriceTransition is a change of the effective price of a phone
+// caused by a campaign starting, ending or being replaced by another one.
+type CampaignPriceTransition struct {
+	PhoneID    int
+	OldPrice   money.Amount
+	NewPrice   money.Amount
+	CampaignID *int
+	Reason     string
+	// Applied is nil when the phone goes back to its base price.
+	Applied *PhoneCampaignPrice
+}
+
+// PlanCampaignPrices compares the campaign prices currently applied with the
+// ones the active campaigns resolve to, and returns the transitions needed to
+// bring them in sync.
+func PlanCampaignPrices(phones []Phone, campaigns []Campaign, current map[int]PhoneCampaignPrice, resolution string) []CampaignPriceTransition {
+	var transitions []CampaignPriceTransition
+	for _, phone := range phones {
+		applied, hasApplied := current[phone.ID]
+		oldPrice := phone.Price
+		if hasApplied {
+			oldPrice = applied.Price
+		}
+
+		winner, price := ResolveCampaign(phone, campaigns, resolution)
+		if winner == nil {
+			if hasApplied {
+				campaignID := applied.CampaignID
+				transitions = append(transitions, CampaignPriceTransition{
+					PhoneID:    phone.ID,
+					OldPrice:   oldPrice,
+					NewPrice:   phone.Price,
+					CampaignID: &campaignID,
+					Reason:     PriceChangeCampaignEnd,
+				})
+			}
+			continue
+		}
+
+		if hasApplied && applied.CampaignID == winner.ID && applied.Price == price {
+			continue
+		}
+		// The same campaign repricing the phone, e.g. after its base price
+		// changed, is not a new campaign start.
+		reason := PriceChangeCampaignStart
+		if hasApplied && applied.CampaignID == winner.ID {
+			reason = PriceChangeCampaignAdjust
+		}
+		campaignID := winner.ID
+		transitions = append(transitions, CampaignPriceTransition{
+			PhoneID:    phone.ID,
+			OldPrice:   oldPrice,
+			NewPrice:   price,
+			CampaignID: &campaignID,
+			Reason:     reason,
+			Applied:    &PhoneCampaignPrice{PhoneID: phone.ID, CampaignID: winner.ID, Price: price},
+		})
+	}
+	return transitions
+}
+
+// Save records the transition in the price history and updates the campaign
+// price applied to the phone.
+func (t *CampaignPriceTransition) Save(tx database.TxQueryer, now time.Time) error {
+	if t.Applied == nil {
+		_, err := tx.Exec("DELETE FROM phone_campaign_prices WHERE phone_id = ?", t.PhoneID)
+		if err != nil {
+			return fmt.Errorf("[CampaignPriceTransition.Save][Delete]%w", err)
+		}
+	} else {
+		query := `
+      INSERT INTO phone_campaign_prices (phone_id, campaign_id, price)
+      VALUES (:phone_id, :campaign_id, :price)
+      ON DUPLICATE KEY UPDATE campaign_id = VALUES(campaign_id), price = VALUES(price);
+    `
+		_, err := tx.NamedExec(query, t.Applied)
+		if err != nil {
+			return fmt.Errorf("[CampaignPriceTransition.Save][Upsert]%w", err)
+		}
+	}
+
+	if t.OldPrice == t.NewPrice {
+		return nil
+	}
+	history := PriceHistory{
+		PhoneID:    t.PhoneID,
+		OldPrice:   t.OldPrice,
+		NewPrice:   t.NewPrice,
+		Reason:     t.Reason,
+		CampaignID: t.CampaignID,
+		ChangedAt:  now,
+	}
+	if err := history.Insert(tx); err != nil {
+		return fmt.Errorf("[CampaignPriceTransition.Save]%w", err)
+	}
+	return nil
+}
+
+func GetPhoneCampaignPrices(db database.Queryer) (map[int]PhoneCampaignPrice, error) {
+	var rows []PhoneCampaignPrice
+	err := db.Select(&rows, "SELECT phone_id, campaign_id, price FROM phone_campaign_prices")
+	if err != nil {
+		return nil, fmt.Errorf("[GetPhoneCampaignPrices][Select]%w", err)
+	}
+	prices := make(map[int]PhoneCampaignPrice, len(rows))
+	for _, row := range rows {
+		prices[row.PhoneID] = row
+	}
+	return prices, nil
+}
+
+// loadEffectivePrices fills the original price, effective price and active
+// campaign of the phones from the campaign prices currently applied.
+func loadEffectivePrices(db database.Queryer, phones []Phone) error {
+	if len(phones) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(phones))
+	for i := range phones {
+		phones[i].OriginalPrice = phones[i].Price
+		phones[i].EffectivePrice = phones[i].Price
+		ids = append(ids, phones[i].ID)
+	}
+
+	query, args, err := sqlx.In(`
+    SELECT pcp.phone_id, pcp.price, c.id, c.name, c.rule_type, c.rule_value, c.ends_at
+    FROM phone_campaign_prices pcp
+    JOIN campaigns c ON c.id = pcp.campaign_id
+    WHERE pcp.phone_id IN (?) AND c.deleted_at IS NULL
+  `, ids)
+	if err != nil {
+		return fmt.Errorf("[loadEffectivePrices][In]%w", err)
+	}
+
+	var rows []struct {
+		PhoneID int          `db:"phone_id"`
+		Price   money.Amount `db:"price"`
+		CampaignSummary
+	}
+	if err := db.Select(&rows, db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("[loadEffectivePrices][Select]%w", err)
+	}
+
+	byPhone := map[int]int{}
+	for i := range phones {
+		byPhone[phones[i].ID] = i
+	}
+	for _, row := range rows {
+		i := byPhone[row.PhoneID]
+		summary := row.CampaignSummary
+		phones[i].EffectivePrice = row.Price
+		phones[i].ActiveCampaign = &summary
+	}
+	return nil
+}
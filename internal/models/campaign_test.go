@@ -0,0 +1,139 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+func TestCampaignApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		campaign Campaign
+		want     money.Amount
+	}{
+		{"percent off", Campaign{RuleType: CampaignRulePercentOff, RuleValue: money.FromInt(15)}, money.FromInt(850)},
+		{"amount off", Campaign{RuleType: CampaignRuleAmountOff, RuleValue: money.FromCents(12050)}, money.FromCents(87950)},
+		{"amount off below zero", Campaign{RuleType: CampaignRuleAmountOff, RuleValue: money.FromInt(2000)}, money.FromInt(0)},
+		{"fixed price", Campaign{RuleType: CampaignRuleFixedPrice, RuleValue: money.FromInt(799)}, money.FromInt(799)},
+		{"fixed price above base price", Campaign{RuleType: CampaignRuleFixedPrice, RuleValue: money.FromInt(1200)}, money.FromInt(1000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.campaign.Apply(money.FromInt(1000)); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolveCampaign(t *testing.T) {
+	phone := Phone{ID: 1, BrandID: 2, Price: money.FromInt(1000), Tags: []Tag{{ID: 3}}}
+	campaigns := []Campaign{
+		{ID: 1, Priority: 1, RuleType: CampaignRulePercentOff, RuleValue: money.FromInt(30), Targets: []CampaignTarget{{TargetType: CampaignTargetTag, TargetID: 3}}},
+		{ID: 2, Priority: 5, RuleType: CampaignRulePercentOff, RuleValue: money.FromInt(10), Targets: []CampaignTarget{{TargetType: CampaignTargetBrand, TargetID: 2}}},
+		{ID: 3, Priority: 9, RuleType: CampaignRulePercentOff, RuleValue: money.FromInt(50), Targets: []CampaignTarget{{TargetType: CampaignTargetPhone, TargetID: 99}}},
+	}
+
+	t.Run("highest priority wins", func(t *testing.T) {
+		winner, price := ResolveCampaign(phone, campaigns, CampaignResolutionPriority)
+		if winner == nil || winner.ID != 2 || price != money.FromInt(900) {
+			t.Errorf("want campaign %v at %v; got %v at %v", 2, 900, winner, price)
+		}
+	})
+
+	t.Run("best price wins", func(t *testing.T) {
+		winner, price := ResolveCampaign(phone, campaigns, CampaignResolutionBestPrice)
+		if winner == nil || winner.ID != 1 || price != money.FromInt(700) {
+			t.Errorf("want campaign %v at %v; got %v at %v", 1, 700, winner, price)
+		}
+	})
+
+	t.Run("keeps base price without matching campaign", func(t *testing.T) {
+		winner, price := ResolveCampaign(Phone{ID: 5, Price: money.FromInt(1000)}, campaigns, CampaignResolutionPriority)
+		if winner != nil || price != money.FromInt(1000) {
+			t.Errorf("want no campaign at %v; got %v at %v", 1000, winner, price)
+		}
+	})
+}
+
+func TestPlanCampaignPrices(t *testing.T) {
+	phones := []Phone{
+		{ID: 1, Price: money.FromInt(1000)},
+		{ID: 2, Price: money.FromInt(500)},
+		{ID: 3, Price: money.FromInt(300)},
+		{ID: 4, Price: money.FromInt(800)},
+	}
+	campaigns := []Campaign{
+		{ID: 7, RuleType: CampaignRuleAmountOff, RuleValue: money.FromInt(100), Targets: []CampaignTarget{
+			{TargetType: CampaignTargetPhone, TargetID: 1},
+			{TargetType: CampaignTargetPhone, TargetID: 3},
+			{TargetType: CampaignTargetPhone, TargetID: 4},
+		}},
+	}
+	current := map[int]PhoneCampaignPrice{
+		2: {PhoneID: 2, CampaignID: 6, Price: money.FromInt(450)},
+		3: {PhoneID: 3, CampaignID: 7, Price: money.FromInt(200)},
+		4: {PhoneID: 4, CampaignID: 7, Price: money.FromInt(600)},
+	}
+
+	transitions := PlanCampaignPrices(phones, campaigns, current, CampaignResolutionPriority)
+	if len(transitions) != 3 {
+		t.Fatalf("want %v transitions; got %v", 3, transitions)
+	}
+
+	t.Run("starts campaigns", func(t *testing.T) {
+		start := transitions[0]
+		if start.PhoneID != 1 || start.Reason != PriceChangeCampaignStart || start.OldPrice != money.FromInt(1000) || start.NewPrice != money.FromInt(900) || start.Applied == nil {
+			t.Errorf("unexpected transition %+v", start)
+		}
+	})
+
+	t.Run("ends campaigns back to the base price", func(t *testing.T) {
+		end := transitions[1]
+		if end.PhoneID != 2 || end.Reason != PriceChangeCampaignEnd || end.OldPrice != money.FromInt(450) || end.NewPrice != money.FromInt(500) || end.Applied != nil || *end.CampaignID != 6 {
+			t.Errorf("unexpected transition %+v", end)
+		}
+	})
+	t.Run("adjusts the price of an applied campaign", func(t *testing.T) {
+		adjust := transitions[2]
+		if adjust.PhoneID != 4 || adjust.Reason != PriceChangeCampaignAdjust || adjust.OldPrice != money.FromInt(600) || adjust.NewPrice != money.FromInt(700) || adjust.Applied == nil {
+			t.Errorf("unexpected transition %+v", adjust)
+		}
+	})
+}
+
+func TestCampaignPriceJumps(t *testing.T) {
+	phones := []Phone{
+		{ID: 1, Price: money.FromInt(39900)},
+		{ID: 2, Price: money.FromInt(4000)},
+		{ID: 3, Price: money.FromInt(39900)},
+	}
+	targets := []CampaignTarget{
+		{TargetType: CampaignTargetPhone, TargetID: 1},
+		{TargetType: CampaignTargetPhone, TargetID: 2},
+	}
+
+	t.Run("flags fixed prices past the threshold", func(t *testing.T) {
+		c := Campaign{RuleType: CampaignRuleFixedPrice, RuleValue: money.FromInt(3990), Targets: targets}
+		jumps := c.PriceJumps(phones, 20)
+		if len(jumps) != 1 || jumps[0].PhoneID != 1 || jumps[0].CampaignPrice != money.FromInt(3990) {
+			t.Errorf("want phone %v flagged; got %+v", 1, jumps)
+		}
+	})
+
+	t.Run("leaves percent rules alone", func(t *testing.T) {
+		c := Campaign{RuleType: CampaignRulePercentOff, RuleValue: money.FromInt(90), Targets: targets}
+		if jumps := c.PriceJumps(phones, 20); len(jumps) != 0 {
+			t.Errorf("want no jumps; got %+v", jumps)
+		}
+	})
+
+	t.Run("disabled without threshold", func(t *testing.T) {
+		c := Campaign{RuleType: CampaignRuleAmountOff, RuleValue: money.FromInt(35000), Targets: targets}
+		if jumps := c.PriceJumps(phones, 0); len(jumps) != 0 {
+			t.Errorf("want no jumps; got %+v", jumps)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+// CanonicalCurrency is the currency of phones.price, every exchange rate
+// converts from it.
+const CanonicalCurrency = "TWD"
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ExchangeRate converts TWD prices into another currency for display only.
+// Rate is the amount of Currency for 1 TWD and converted prices are rounded
+// to the nearest RoundingStep, for example 1000 for IDR.
+type ExchangeRate struct {
+	Currency     string       `db:"currency" json:"currency"`
+	Rate         float64      `db:"rate" json:"rate"`
+	RoundingStep money.Amount `db:"rounding_step" json:"rounding_step"`
+	CreatedAt    time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time    `db:"updated_at" json:"updated_at"`
+}
+
+// DisplayPrices are the phone prices converted with an exchange rate.
+type DisplayPrices struct {
+	Currency       string       `json:"currency"`
+	Rate           float64      `json:"rate"`
+	Price          money.Amount `json:"price"`
+	OriginalPrice  money.Amount `json:"original_price"`
+	EffectivePrice money.Amount `json:"effective_price"`
+}
+
+// IsCurrencyCode reports whether code looks like an uppercase ISO 4217 code.
+func IsCurrencyCode(code string) bool {
+	return currencyCode.MatchString(code)
+}
+
+// Save inserts the rate or replaces the one already stored for the currency.
+func (e *ExchangeRate) Save(tx database.TxQueryer) error {
+	query := `
+    INSERT INTO exchange_rates (currency, rate, rounding_step)
+    VALUES (:currency, :rate, :rounding_step)
+    ON DUPLICATE KEY UPDATE rate = VALUES(rate), rounding_step = VALUES(rounding_step), updated_at = CURRENT_TIMESTAMP;
+  `
+	_, err := tx.NamedExec(query, e)
+	if err != nil {
+		return fmt.Errorf("[ExchangeRate.Save][NamedExec]%w", err)
+	}
+	return nil
+}
+
+func (e *ExchangeRate) Delete(tx database.TxQueryer) error {
+	_, err := tx.Exec("DELETE FROM exchange_rates WHERE currency = ?", e.Currency)
+	if err != nil {
+		return fmt.Errorf("[ExchangeRate.Delete][Exec]%w", err)
+	}
+	return nil
+}
+
+// Convert converts a TWD price and rounds it half up to the nearest rounding
+// step.
+func (e *ExchangeRate) Convert(price money.Amount) money.Amount {
+	return price.Mul(e.Rate, money.HalfUp).RoundTo(e.RoundingStep, money.HalfUp)
+}
+
+// DisplayPrices converts the prices of a phone read from the catalog.
+func (e *ExchangeRate) DisplayPrices(p Phone) *DisplayPrices {
+	return &DisplayPrices{
+		Currency:       e.Currency,
+		Rate:           e.Rate,
+		Price:          e.Convert(p.Price),
+		OriginalPrice:  e.Convert(p.OriginalPrice),
+		EffectivePrice: e.Convert(p.EffectivePrice),
+	}
+}
+
+func GetExchangeRate(db database.Queryer, currency string) (*ExchangeRate, bool, error) {
+	var rate ExchangeRate
+	err := db.Get(&rate, "SELECT * FROM exchange_rates WHERE currency = ?", currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("[GetExchangeRate][Get]%w", err)
+	}
+	return &rate, true, nil
+}
+
+func GetExchangeRates(db database.Queryer) ([]ExchangeRate, error) {
+	rates := []ExchangeRate{}
+	err := db.Select(&rates, "SELECT * FROM exchange_rates ORDER BY currency")
+	if err != nil {
+		return nil, fmt.Errorf("[GetExchangeRates][Select]%w", err)
+	}
+	return rates, nil
+}
+
+// ParseExchangeRatesCSV reads "currency,rate[,rounding_step]" rows. A header
+// row starting with "currency" is skipped and the rounding step defaults to 1.
+func ParseExchangeRatesCSV(r io.Reader) ([]ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rates := []ExchangeRate{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("[ParseExchangeRatesCSV][Read]%w", err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "currency") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %d: expected currency,rate[,rounding_step]", line)
+		}
+
+		rate := ExchangeRate{Currency: strings.ToUpper(strings.TrimSpace(record[0])), RoundingStep: money.FromInt(1)}
+		if !IsCurrencyCode(rate.Currency) || rate.Currency == CanonicalCurrency {
+			return nil, fmt.Errorf("line %d: invalid currency %q", line, record[0])
+		}
+		rate.Rate, err = strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil || rate.Rate <= 0 {
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, record[1])
+		}
+		if len(record) == 3 && strings.TrimSpace(record[2]) != "" {
+			rate.RoundingStep, err = money.Parse(record[2])
+			if err != nil || rate.RoundingStep <= 0 {
+				return nil, fmt.Errorf("line %d: invalid rounding step %q", line, record[2])
+			}
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
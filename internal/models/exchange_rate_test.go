@@ -0,0 +1,44 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+func TestExchangeRateConvert(t *testing.T) {
+	tests := []struct {
+		name string
+		rate ExchangeRate
+		want money.Amount
+	}{
+		{"rounds to the step", ExchangeRate{Currency: "IDR", Rate: 497.35, RoundingStep: money.FromInt(1000)}, money.FromInt(9_942_000)},
+		{"keeps cents without step", ExchangeRate{Currency: "USD", Rate: 0.0312}, money.FromCents(62369)},
+		{"rounds to whole units", ExchangeRate{Currency: "VND", Rate: 781.2, RoundingStep: money.FromInt(1)}, money.FromInt(15_616_188)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rate.Convert(money.FromInt(19990)); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseExchangeRatesCSV(t *testing.T) {
+	rates, err := ParseExchangeRatesCSV(strings.NewReader("currency,rate,rounding_step\nidr, 497.35, 1000\nVND,781.2\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rates) != 2 || rates[0].Currency != "IDR" || rates[0].RoundingStep != money.FromInt(1000) || rates[1].RoundingStep != money.FromInt(1) {
+		t.Errorf("unexpected rates: %+v", rates)
+	}
+
+	for _, input := range []string{"TWD,1\n", "IDR,-1\n", "IDR\n", "IDRX,1\n"} {
+		if _, err := ParseExchangeRatesCSV(strings.NewReader(input)); err == nil {
+			t.Errorf("want error for %q", input)
+		}
+	}
+}
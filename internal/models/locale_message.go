@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+)
+
+// LocaleMessage overrides the message of the locale files for a language,
+// so translations can be fixed without a deploy.
+type LocaleMessage struct {
+	ID        int       `db:"id" json:"id"`
+	Locale    string    `db:"locale" json:"locale"`
+	MessageID string    `db:"message_id" json:"message_id"`
+	Message   string    `db:"message" json:"message"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Save inserts the message or replaces the one already stored for the
+// locale and message ID.
+func (m *LocaleMessage) Save(tx database.TxQueryer) error {
+	query := `
+    INSERT INTO locale_messages (locale, message_id, message)
+    VALUES (:locale, :message_id, :message)
+    ON DUPLICATE KEY UPDATE message = VALUES(message), updated_at = CURRENT_TIMESTAMP;
+  `
+	_, err := tx.NamedExec(query, m)
+	if err != nil {
+		return fmt.Errorf("[LocaleMessage.Save][NamedExec]%w", err)
+	}
+	return nil
+}
+
+// DeleteLocaleMessages removes the overrides of the given message IDs, so
+// the locale files apply again.
+func DeleteLocaleMessages(tx database.TxQueryer, locale string, messageIDs ...string) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	args := []any{locale}
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+	_, err := tx.Exec(
+		"DELETE FROM locale_messages WHERE locale = ? AND message_id IN (?"+strings.Repeat(", ?", len(messageIDs)-1)+")",
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("[DeleteLocaleMessages][Exec]%w", err)
+	}
+	return nil
+}
+
+func GetLocaleMessages(db database.Queryer) ([]LocaleMessage, error) {
+	messages := []LocaleMessage{}
+	err := db.Select(&messages, "SELECT * FROM locale_messages ORDER BY locale, message_id")
+	if err != nil {
+		return nil, fmt.Errorf("[GetLocaleMessages][Select]%w", err)
+	}
+	return messages, nil
+}
+
+// GetLocaleMessageOverrides returns the stored messages keyed by locale then
+// message ID, the shape Localizer.Reload takes.
+func GetLocaleMessageOverrides(db database.Queryer) (map[string]map[string]string, error) {
+	messages, err := GetLocaleMessages(db)
+	if err != nil {
+		return nil, fmt.Errorf("[GetLocaleMessageOverrides]%w", err)
+	}
+
+	overrides := map[string]map[string]string{}
+	for _, m := range messages {
+		if overrides[m.Locale] == nil {
+			overrides[m.Locale] = map[string]string{}
+		}
+		overrides[m.Locale][m.MessageID] = m.Message
+	}
+	return overrides, nil
+}
+
+// GetLocaleMessagesVersion returns a checksum of the stored messages, which
+// changes whenever one is saved or deleted, letting every instance know when
+// to reload its localizer.
+func GetLocaleMessagesVersion(db database.Queryer) (string, error) {
+	var version string
+	err := db.Get(
+		&version,
+		"SELECT CONCAT(COUNT(*), ':', COALESCE(SUM(CRC32(CONCAT_WS(CHAR(31), locale, message_id, message))), 0)) FROM locale_messages",
+	)
+	if err != nil {
+		return "", fmt.Errorf("[GetLocaleMessagesVersion][Get]%w", err)
+	}
+	return version, nil
+}
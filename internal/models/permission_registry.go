@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+)
+
+const (
+	PermissionAdminIndex         = "admin::index"
+	PermissionAdminManage        = "admin::manage"
+	PermissionRoleManage         = "admin::role.manage"
+	PermissionSystemManage       = "admin::system.manage"
+	PermissionLocaleManage       = "admin::locale.manage"
+	PermissionPriceApprove       = "catalog::price.approve"
+	PermissionPhoneCreate        = "catalog::phone.create"
+	PermissionPhoneUpdate        = "catalog::phone.update"
+	PermissionPhoneDelete        = "catalog::phone.delete"
+	PermissionCampaignManage     = "catalog::campaign.manage"
+	PermissionPriceRuleManage    = "catalog::price_rule.manage"
+	PermissionExchangeRateManage = "catalog::exchange_rate.manage"
+	PermissionTranslationManage  = "catalog::translation.manage"
+)
+
+// PermissionRegistry declares every permission checked in the code. It is
+// synced to the permissions table at startup, so a permission only has to be
+// added here to be assignable to roles.
+var PermissionRegistry = []Permission{
+	{Identifier: PermissionAdminIndex, Module: "admin", Name: "List admins", Description: "See the admins and their roles"},
+	{Identifier: PermissionAdminManage, Module: "admin", Name: "Manage admins", Description: "Change the role of admins and deactivate or reactivate them"},
+	{Identifier: PermissionRoleManage, Module: "admin", Name: "Manage roles", Description: "Create, rename and delete roles and choose their permissions"},
+	{Identifier: PermissionSystemManage, Module: "admin", Name: "Manage systems", Description: "Register partner systems, rotate their secrets and revoke them"},
+	{Identifier: PermissionLocaleManage, Module: "admin", Name: "Manage locales", Description: "Override the interface messages of the locales"},
+	{Identifier: PermissionPriceApprove, Module: "catalog", Name: "Approve price changes", Description: "Approve or reject price changes waiting for a second admin"},
+	{Identifier: PermissionPhoneCreate, Module: "catalog", Name: "Create phones", Description: "Add phones to the catalog"},
+	{Identifier: PermissionPhoneUpdate, Module: "catalog", Name: "Update phones", Description: "Edit the phones of the catalog"},
+	{Identifier: PermissionPhoneDelete, Module: "catalog", Name: "Delete phones", Description: "Remove phones from the catalog"},
+	{Identifier: PermissionCampaignManage, Module: "catalog", Name: "Manage campaigns", Description: "Create, edit and delete price campaigns"},
+	{Identifier: PermissionPriceRuleManage, Module: "catalog", Name: "Manage price rules", Description: "Create, edit, preview and delete the rules applied to phone prices"},
+	{Identifier: PermissionExchangeRateManage, Module: "catalog", Name: "Manage exchange rates", Description: "Set and delete the exchange rates used for display prices"},
+	{Identifier: PermissionTranslationManage, Module: "catalog", Name: "Manage translations", Description: "Edit and delete the translations of catalog entries"},
+}
+
+// IsRegisteredPermission reports whether identifier is in PermissionRegistry.
+func IsRegisteredPermission(identifier string) bool {
+	for _, p := range PermissionRegistry {
+		if p.Identifier == identifier {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncPermissions inserts the registered permissions missing from the
+// permissions table and refreshes the module, name and description of the
+// others. Permissions no longer registered are left for an admin to remove.
+func SyncPermissions(db database.Queryer) error {
+	now := time.Now().Unix()
+	for _, p := range PermissionRegistry {
+		p.CreatedAt = now
+		p.UpdatedAt = now
+		query := `
+    INSERT INTO permissions (identifier, module, name, description, created_at, updated_at)
+    VALUES (:identifier, :module, :name, :description, :created_at, :updated_at)
+    ON DUPLICATE KEY UPDATE module = VALUES(module), name = VALUES(name), description = VALUES(description), updated_at = VALUES(updated_at);
+  `
+		if _, err := db.NamedExec(query, p); err != nil {
+			return fmt.Errorf("[SyncPermissions][NamedExec] %s: %w", p.Identifier, err)
+		}
+	}
+	return nil
+}
+
+// GetRolePermissionIdentifiers returns the identifiers of the permissions
+// assigned to a role.
+func GetRolePermissionIdentifiers(db database.Queryer, roleID string) ([]string, error) {
+	identifiers := []string{}
+	err := db.Select(
+		&identifiers,
+		"SELECT permissions.identifier FROM authorities JOIN permissions ON permissions.id = authorities.permission_id WHERE authorities.role_id = ? ORDER BY permissions.identifier",
+		roleID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("[GetRolePermissionIdentifiers][Select]%w", err)
+	}
+	return identifiers, nil
+}
@@ -1,25 +1,51 @@
 package models
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
-	"net/http"
+	"strings"
 	"time"
 
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/pricing"
 	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
 )
 
 type Phone struct {
-	ID             int        `db:"id" json:"id"`
-	Name           string     `db:"name" json:"name"`
-	BrandID        int        `db:"brand_id" json:"brand_id"`
-	BrandName      string     `db:"brand_name" json:"brand_name"`
-	Specifications string     `db:"specifications" json:"specifications"`
-	Price          float64    `db:"price" json:"price"`
-	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
-	DeletedAt      *time.Time `db:"deleted_at" json:"deleted_at"`
-	PublishedAt    *time.Time `db:"published_at" json:"published_at"`
-	Tags           []Tag      `json:"tags"`
+	ID             int           `db:"id" json:"id"`
+	Name           string        `db:"name" json:"name"`
+	BrandID        int           `db:"brand_id" json:"brand_id"`
+	BrandName      string        `db:"brand_name" json:"brand_name"`
+	Specifications string        `db:"specifications" json:"specifications"`
+	Price          money.Amount  `db:"price" json:"price"`
+	CostPrice      *money.Amount `db:"cost_price" json:"cost_price"`
+	CreatedAt      time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time     `db:"updated_at" json:"updated_at"`
+	DeletedAt      *time.Time    `db:"deleted_at" json:"deleted_at"`
+	PublishedAt    *time.Time    `db:"published_at" json:"published_at"`
+	Tags           []Tag         `json:"tags"`
+
+	CompatibleAccessories []PhoneSummary `json:"compatible_accessories,omitempty"`
+	CompatibleDevices     []PhoneSummary `json:"compatible_devices,omitempty"`
+
+	// OriginalPrice and EffectivePrice are only filled when reading phones.
+	// Price is always the base price, EffectivePrice is what customers pay
+	// while ActiveCampaign runs.
+	OriginalPrice  money.Amount     `db:"-" json:"original_price"`
+	EffectivePrice money.Amount     `db:"-" json:"effective_price"`
+	ActiveCampaign *CampaignSummary `db:"-" json:"active_campaign"`
+	// DisplayPrices is set when the catalog is read with a currency.
+	DisplayPrices *DisplayPrices `db:"-" json:"display_prices,omitempty"`
+
+	// PriceChangeReason is recorded in the price history by Update, it
+	// defaults to PriceChangeManual.
+	PriceChangeReason string `db:"-" json:"-"`
+
+	// ConfirmPriceChange lets Insert and Update go past max step price rules.
+	// PriceAdjustments lists the price rules that changed the requested price.
+	ConfirmPriceChange bool                 `db:"-" json:"confirm_price_change,omitempty"`
+	PriceAdjustments   []pricing.Adjustment `db:"-" json:"price_adjustments,omitempty"`
 }
 
 type Tag struct {
@@ -28,21 +54,36 @@ type Tag struct {
 }
 
 type Installment struct {
-	ID           int       `db:"id" json:"id"`
-	PhoneID      int       `db:"phone_id" json:"phone_id"`
-	ThreeMonths  float64   `db:"three_months" json:"three_months"`
-	SixMonths    float64   `db:"six_months" json:"six_months"`
-	TwelveMonths float64   `db:"twelve_months" json:"twelve_months"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	ID      int `db:"id" json:"id"`
+	PhoneID int `db:"phone_id" json:"phone_id"`
+	// The monthly amounts are rounded down to the cent, the first payment of
+	// each plan takes the cents left over so a plan always adds up to the price.
+	ThreeMonths       money.Amount `db:"three_months" json:"three_months"`
+	ThreeMonthsFirst  money.Amount `db:"three_months_first" json:"three_months_first"`
+	SixMonths         money.Amount `db:"six_months" json:"six_months"`
+	SixMonthsFirst    money.Amount `db:"six_months_first" json:"six_months_first"`
+	TwelveMonths      money.Amount `db:"twelve_months" json:"twelve_months"`
+	TwelveMonthsFirst money.Amount `db:"twelve_months_first" json:"twelve_months_first"`
+	CreatedAt         time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time    `db:"updated_at" json:"updated_at"`
 }
 
+const (
+	PriceChangeManual         = "manual"
+	PriceChangeScheduled      = "scheduled"
+	PriceChangeCampaignStart  = "campaign_start"
+	PriceChangeCampaignAdjust = "campaign_adjust"
+	PriceChangeCampaignEnd    = "campaign_end"
+)
+
 type PriceHistory struct {
-	ID        int       `db:"id" json:"id"`
-	PhoneID   int       `db:"phone_id" json:"phone_id"`
-	OldPrice  float64   `db:"old_price" json:"old_price"`
-	NewPrice  float64   `db:"new_price" json:"new_price"`
-	ChangedAt time.Time `db:"changed_at" json:"changed_at"`
+	ID         int          `db:"id" json:"id"`
+	PhoneID    int          `db:"phone_id" json:"phone_id"`
+	OldPrice   money.Amount `db:"old_price" json:"old_price"`
+	NewPrice   money.Amount `db:"new_price" json:"new_price"`
+	Reason     string       `db:"reason" json:"reason"`
+	CampaignID *int         `db:"campaign_id" json:"campaign_id"`
+	ChangedAt  time.Time    `db:"changed_at" json:"changed_at"`
 }
 
 type Brand struct {
@@ -52,8 +93,6 @@ type Brand struct {
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
 
-func (b *Brand) Bind(r *http.Request) error { return nil }
-
 func (b *Brand) Insert(tx database.TxQueryer) error {
 	query := `INSERT INTO brands (name) VALUES (:name);`
 	_, err := tx.NamedExec(query, b)
@@ -99,17 +138,18 @@ func GetBrand(db database.Queryer, id int) (Brand, error) {
 	return brand, err
 }
 
-func (p *Phone) Bind(r *http.Request) error {
-	return nil
-}
+// Insert saves a new phone, its price adjusted by the given pricing rules.
+func (p *Phone) Insert(tx database.TxQueryer, rules []pricing.Rule) error {
+	if err := p.applyPriceRules(rules, nil); err != nil {
+		return fmt.Errorf("[Phone][Insert]%w", err)
+	}
 
-func (p *Phone) Insert(tx database.TxQueryer) error {
 	query := `
-    INSERT INTO phones (name, brand_id, specifications, price) 
-    VALUES (?, ?, ?, ?)
+    INSERT INTO phones (name, brand_id, specifications, price, cost_price) 
+    VALUES (?, ?, ?, ?, ?)
     RETURNING id
     `
-	err := tx.QueryRow(query, p.Name, p.BrandID, p.Specifications, p.Price).Scan(&p.ID)
+	err := tx.QueryRow(query, p.Name, p.BrandID, p.Specifications, p.Price, p.CostPrice).Scan(&p.ID)
 	if err != nil {
 		return fmt.Errorf("[Phone][Insert][QueryRow] %w", err)
 	}
@@ -125,19 +165,36 @@ func (p *Phone) Insert(tx database.TxQueryer) error {
 	return nil
 }
 
-func (p *Phone) Update(tx database.TxQueryer) error {
+// Update saves the phone. The given pricing rules only run when the price or
+// the cost price changes, other edits keep the stored price as it is.
+func (p *Phone) Update(tx database.TxQueryer, rules []pricing.Rule) error {
 	// Get the old price
-	var oldPrice float64
-	err := tx.Get(&oldPrice, "SELECT price FROM phones WHERE id=?", p.ID)
+	var old struct {
+		Price     money.Amount  `db:"price"`
+		CostPrice *money.Amount `db:"cost_price"`
+	}
+	err := tx.Get(&old, "SELECT price, cost_price FROM phones WHERE id=?", p.ID)
 	if err != nil {
 		return fmt.Errorf("[Phone.Update][Get old price]%w", err)
 	}
+	oldPrice := old.Price
+
+	if p.Price != oldPrice || !sameAmount(p.CostPrice, old.CostPrice) {
+		if err := p.applyPriceRules(rules, &oldPrice); err != nil {
+			return fmt.Errorf("[Phone.Update]%w", err)
+		}
+	}
 
 	// Insert price change into PriceHistory
+	reason := p.PriceChangeReason
+	if reason == "" {
+		reason = PriceChangeManual
+	}
 	priceHistory := PriceHistory{
 		PhoneID:   p.ID,
 		OldPrice:  oldPrice,
 		NewPrice:  p.Price,
+		Reason:    reason,
 		ChangedAt: time.Now(),
 	}
 	err = priceHistory.Insert(tx)
@@ -147,7 +204,7 @@ func (p *Phone) Update(tx database.TxQueryer) error {
 
 	// Update the phone record
 	query := `
-    UPDATE phones SET name = :name, brand_id = :brand_id, specifications = :specifications, price = :price, published_at = :published_at, updated_at = CURRENT_TIMESTAMP
+    UPDATE phones SET name = :name, brand_id = :brand_id, specifications = :specifications, price = :price, cost_price = :cost_price, published_at = :published_at, updated_at = CURRENT_TIMESTAMP
     WHERE id = :id;
   `
 	_, err = tx.NamedExec(query, p)
@@ -169,35 +226,52 @@ func (p *Phone) Update(tx database.TxQueryer) error {
 	return nil
 }
 
+func GetPhonePrice(tx database.TxQueryer, id int) (money.Amount, error) {
+	var price money.Amount
+	err := tx.Get(&price, "SELECT price FROM phones WHERE id = ?", id)
+	if err != nil {
+		return 0, fmt.Errorf("[GetPhonePrice][Get]%w", err)
+	}
+	return price, nil
+}
+
+// IsPublished reports whether the phone is visible in the catalog at now.
+func (p *Phone) IsPublished(now time.Time) bool {
+	return p.DeletedAt == nil && p.PublishedAt != nil && !p.PublishedAt.After(now)
+}
+
 func (p *Phone) Delete(tx database.TxQueryer) error {
 	query := "UPDATE phones SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?;"
 	_, err := tx.Exec(query, p.ID)
 	if err != nil {
 		return fmt.Errorf("[Phone.Delete][Exec]%w", err)
 	}
+	if err := CancelScheduledPriceChanges(tx, p.ID); err != nil {
+		return fmt.Errorf("[Phone.Delete]%w", err)
+	}
 	return nil
 }
 func GetPhones(db database.Queryer, limit, offset int, sortBy, order, filterBy, filterValue string) ([]Phone, error) {
 	phones := []Phone{}
 	baseQuery := `
-    SELECT phones.id, phones.name, phones.brand_id, brands.name AS brand_name, phones.specifications, phones.price, phones.created_at, phones.updated_at, phones.deleted_at, phones.published_at 
+    SELECT phones.id, phones.name, phones.brand_id, brands.name AS brand_name, phones.specifications, phones.price, phones.cost_price, phones.created_at, phones.updated_at, phones.deleted_at, phones.published_at 
     FROM phones 
     JOIN brands ON phones.brand_id = brands.id
     WHERE phones.deleted_at IS NULL
     `
-	filterQuery := ""
-	if filterBy != "" && filterValue != "" {
-		filterQuery = fmt.Sprintf("AND %s LIKE '%%%s%%'", filterBy, filterValue)
+	filterQuery, args, err := PhoneFilterClause(filterBy, filterValue)
+	if err != nil {
+		return nil, fmt.Errorf("[GetPhones]%w", err)
 	}
-	sortQuery := ""
-	if sortBy != "" && order != "" {
-		sortQuery = fmt.Sprintf("ORDER BY %s %s", sortBy, order)
+	sortQuery, err := PhoneSortClause(sortBy, order)
+	if err != nil {
+		return nil, fmt.Errorf("[GetPhones]%w", err)
 	}
 	paginationQuery := fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
 
 	query := fmt.Sprintf("%s %s %s %s", baseQuery, filterQuery, sortQuery, paginationQuery)
 
-	err := db.Select(&phones, query)
+	err = db.Select(&phones, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("[GetPhones][Select]%w", err)
 	}
@@ -210,13 +284,85 @@ func GetPhones(db database.Queryer, limit, offset int, sortBy, order, filterBy,
 		phones[i].Tags = tags
 	}
 
+	if err := loadEffectivePrices(db, phones); err != nil {
+		return nil, fmt.Errorf("[GetPhones]%w", err)
+	}
+
 	return phones, nil
 }
 
+var (
+	ErrInvalidPhoneFilter = errors.New("invalid phone filter")
+	ErrInvalidPhoneSort   = errors.New("invalid phone sort")
+	ErrInvalidPhoneOrder  = errors.New("invalid phone order")
+)
+
+// phoneSortColumns maps the sortBy values accepted by the phone listing to
+// the column they sort on.
+var phoneSortColumns = map[string]string{
+	"id":                  "phones.id",
+	"phones.id":           "phones.id",
+	"name":                "phones.name",
+	"phones.name":         "phones.name",
+	"brand_name":          "brands.name",
+	"brands.name":         "brands.name",
+	"price":               "phones.price",
+	"phones.price":        "phones.price",
+	"created_at":          "phones.created_at",
+	"phones.created_at":   "phones.created_at",
+	"updated_at":          "phones.updated_at",
+	"phones.updated_at":   "phones.updated_at",
+	"published_at":        "phones.published_at",
+	"phones.published_at": "phones.published_at",
+}
+
+// PhoneSortClause builds the "ORDER BY" clause of the phone listing, empty
+// unless both the column and the direction are given.
+func PhoneSortClause(sortBy, order string) (string, error) {
+	if sortBy == "" || order == "" {
+		return "", nil
+	}
+	column, ok := phoneSortColumns[sortBy]
+	if !ok {
+		return "", fmt.Errorf("[PhoneSortClause]%w: %s", ErrInvalidPhoneSort, sortBy)
+	}
+	direction := strings.ToUpper(order)
+	if direction != "ASC" && direction != "DESC" {
+		return "", fmt.Errorf("[PhoneSortClause]%w: %s", ErrInvalidPhoneOrder, order)
+	}
+	return fmt.Sprintf("ORDER BY %s %s", column, direction), nil
+}
+
+// phoneFilterColumns maps the filterBy values accepted by the phone listing to
+// the expression they match against.
+var phoneFilterColumns = map[string]string{
+	"name":                  "phones.name",
+	"phones.name":           "phones.name",
+	"brand":                 "brands.name",
+	"brand_name":            "brands.name",
+	"brands.name":           "brands.name",
+	"specifications":        "phones.specifications",
+	"phones.specifications": "phones.specifications",
+	"tag":                   "(SELECT GROUP_CONCAT(tags.name) FROM phone_tags JOIN tags ON tags.id = phone_tags.tag_id WHERE phone_tags.phone_id = phones.id)",
+}
+
+// PhoneFilterClause builds the "AND ... LIKE ?" condition of the phone
+// listing filter. The clause expects phones joined with brands.
+func PhoneFilterClause(filterBy, filterValue string) (string, []any, error) {
+	if filterBy == "" || filterValue == "" {
+		return "", nil, nil
+	}
+	column, ok := phoneFilterColumns[filterBy]
+	if !ok {
+		return "", nil, fmt.Errorf("[PhoneFilterClause]%w: %s", ErrInvalidPhoneFilter, filterBy)
+	}
+	return fmt.Sprintf("AND %s LIKE ?", column), []any{"%" + filterValue + "%"}, nil
+}
+
 func GetPhone(db database.Queryer, id int) (Phone, error) {
 	phone := Phone{}
 	query := `
-    SELECT phones.id, phones.name, phones.brand_id, brands.name AS brand_name, phones.specifications, phones.price, phones.created_at, phones.updated_at, phones.deleted_at, phones.published_at 
+    SELECT phones.id, phones.name, phones.brand_id, brands.name AS brand_name, phones.specifications, phones.price, phones.cost_price, phones.created_at, phones.updated_at, phones.deleted_at, phones.published_at 
     FROM phones 
     JOIN brands ON phones.brand_id = brands.id 
     WHERE phones.id = ? AND phones.deleted_at IS NULL;
@@ -232,9 +378,39 @@ func GetPhone(db database.Queryer, id int) (Phone, error) {
 	}
 	phone.Tags = tags
 
+	phones := []Phone{phone}
+	if err := loadEffectivePrices(db, phones); err != nil {
+		return Phone{}, fmt.Errorf("[GetPhone]%w", err)
+	}
+	phone = phones[0]
+
 	return phone, nil
 }
 
+// PhoneNameExists reports whether another phone of the brand, not deleted
+// and other than exceptID, already has the name.
+func PhoneNameExists(db database.Queryer, brandID int, name string, exceptID int) (bool, error) {
+	var exists bool
+	err := db.Get(
+		&exists,
+		"SELECT EXISTS(SELECT 1 FROM phones WHERE brand_id = ? AND name = ? AND id <> ? AND deleted_at IS NULL)",
+		brandID, name, exceptID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("[PhoneNameExists][Get]%w", err)
+	}
+	return exists, nil
+}
+
+func GetTag(db database.Queryer, id int) (Tag, error) {
+	tag := Tag{}
+	err := db.Get(&tag, "SELECT id, name FROM tags WHERE id = ?", id)
+	if err != nil {
+		return Tag{}, fmt.Errorf("[GetTag][Get]%w", err)
+	}
+	return tag, nil
+}
+
 func GetTagsForPhone(db database.Queryer, phoneID int) ([]Tag, error) {
 	var tags []Tag
 
@@ -252,14 +428,10 @@ func GetTagsForPhone(db database.Queryer, phoneID int) ([]Tag, error) {
 	return tags, nil
 }
 
-func (i *Installment) Bind(r *http.Request) error {
-	return nil
-}
-
 func (i *Installment) Insert(tx database.TxQueryer) error {
 	query := `
-    INSERT INTO installments (phone_id, three_months, six_months, twelve_months) 
-    VALUES (:phone_id, :three_months, :six_months, :twelve_months);
+    INSERT INTO installments (phone_id, three_months, three_months_first, six_months, six_months_first, twelve_months, twelve_months_first) 
+    VALUES (:phone_id, :three_months, :three_months_first, :six_months, :six_months_first, :twelve_months, :twelve_months_first);
   `
 	_, err := tx.NamedExec(query, i)
 	if err != nil {
@@ -268,22 +440,34 @@ func (i *Installment) Insert(tx database.TxQueryer) error {
 	return nil
 }
 
-func CalculateInstallments(price float64) Installment {
-	return Installment{
-		ThreeMonths:  price / 3,
-		SixMonths:    price / 6,
-		TwelveMonths: price / 12,
+// ReplaceInstallments recalculates the installment plans of a phone for a new
+// price, discarding the previous ones.
+func ReplaceInstallments(tx database.TxQueryer, phoneID int, price money.Amount) error {
+	_, err := tx.Exec("DELETE FROM installments WHERE phone_id = ?", phoneID)
+	if err != nil {
+		return fmt.Errorf("[ReplaceInstallments][Delete]%w", err)
 	}
-}
 
-func (ph *PriceHistory) Bind(r *http.Request) error {
+	installment := CalculateInstallments(price)
+	installment.PhoneID = phoneID
+	if err := installment.Insert(tx); err != nil {
+		return fmt.Errorf("[ReplaceInstallments]%w", err)
+	}
 	return nil
 }
 
+func CalculateInstallments(price money.Amount) Installment {
+	var i Installment
+	i.ThreeMonthsFirst, i.ThreeMonths = price.Split(3)
+	i.SixMonthsFirst, i.SixMonths = price.Split(6)
+	i.TwelveMonthsFirst, i.TwelveMonths = price.Split(12)
+	return i
+}
+
 func (ph *PriceHistory) Insert(tx database.TxQueryer) error {
 	query := `
-    INSERT INTO price_history (phone_id, old_price, new_price, changed_at) 
-    VALUES (:phone_id, :old_price, :new_price, :changed_at);
+    INSERT INTO price_history (phone_id, old_price, new_price, reason, campaign_id, changed_at) 
+    VALUES (:phone_id, :old_price, :new_price, :reason, :campaign_id, :changed_at);
   `
 	_, err := tx.NamedExec(query, ph)
 	if err != nil {
@@ -291,3 +475,64 @@ func (ph *PriceHistory) Insert(tx database.TxQueryer) error {
 	}
 	return nil
 }
+
+// GetPriceHistory returns the price changes of a phone, latest first.
+func GetPriceHistory(db database.Queryer, phoneID int) ([]PriceHistory, error) {
+	history := []PriceHistory{}
+	err := db.Select(
+		&history,
+		"SELECT id, phone_id, old_price, new_price, reason, campaign_id, changed_at FROM price_history WHERE phone_id = ? ORDER BY changed_at DESC, id DESC",
+		phoneID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("[GetPriceHistory][Select]%w", err)
+	}
+	return history, nil
+}
+
+func GetInstallmentByPhoneID(db database.Queryer, phoneID int) (*Installment, bool, error) {
+	var installment Installment
+	err := db.Get(&installment, "SELECT * FROM installments WHERE phone_id = ? ORDER BY id DESC LIMIT 1", phoneID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("[GetInstallmentByPhoneID][Get]%w", err)
+	}
+	return &installment, true, nil
+}
+
+// Cheapest returns the installment plan with the lowest monthly amount. Plans
+// without an amount are skipped, so a zero-priced phone has no cheapest plan.
+func (i *Installment) Cheapest() (InstallmentOption, bool) {
+	options := []InstallmentOption{
+		{Months: 3, MonthlyAmount: i.ThreeMonths, FirstPayment: i.ThreeMonthsFirst},
+		{Months: 6, MonthlyAmount: i.SixMonths, FirstPayment: i.SixMonthsFirst},
+		{Months: 12, MonthlyAmount: i.TwelveMonths, FirstPayment: i.TwelveMonthsFirst},
+	}
+
+	var cheapest InstallmentOption
+	found := false
+	for _, option := range options {
+		if option.MonthlyAmount <= 0 {
+			continue
+		}
+		if !found || option.MonthlyAmount < cheapest.MonthlyAmount {
+			cheapest = option
+			found = true
+		}
+	}
+	return cheapest, found
+}
+
+type InstallmentOption struct {
+	Months        int          `json:"months"`
+	MonthlyAmount money.Amount `json:"monthly_amount"`
+	FirstPayment  money.Amount `json:"first_payment"`
+}
+
+func sameAmount(a, b *money.Amount) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/pricing"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+const (
+	BulkOpAddTag         = "add_tag"
+	BulkOpRemoveTag      = "remove_tag"
+	BulkOpSetPublishedAt = "set_published_at"
+	BulkOpAdjustPrice    = "adjust_price_percent"
+	BulkOpSetPrice       = "set_price"
+)
+
+const (
+	BulkResultUpdated         = "updated"
+	BulkResultUnchanged       = "unchanged"
+	BulkResultPendingApproval = "pending_approval"
+	BulkResultFailed          = "failed"
+)
+
+// BulkSelector picks the phones of a bulk edit, either by ID or with the same
+// filter as the phone listing, optionally narrowed to a brand.
+type BulkSelector struct {
+	IDs         []int  `json:"ids"`
+	FilterBy    string `json:"filter_by"`
+	FilterValue string `json:"filter_value"`
+	BrandID     int    `json:"brand_id"`
+}
+
+type BulkOperation struct {
+	Op          string       `json:"op"`
+	TagID       int          `json:"tag_id,omitempty"`
+	PublishedAt *time.Time   `json:"published_at,omitempty"`
+	Percent     float64      `json:"percent,omitempty"`
+	Price       money.Amount `json:"price,omitempty"`
+}
+
+type BulkOptions struct {
+	ActorID            string
+	ConfirmPriceChange bool
+	// ApprovalThreshold is the PriceApproval threshold, price moves above it
+	// become price change requests instead of being applied.
+	ApprovalThreshold float64
+	// PriceRules are the pricing rules run on the changed prices.
+	PriceRules []pricing.Rule
+}
+
+type BulkResult struct {
+	PhoneID            int                    `json:"phone_id"`
+	Name               string                 `json:"name"`
+	Status             string                 `json:"status"`
+	Changes            map[string]FieldChange `json:"changes"`
+	PriceAdjustments   []pricing.Adjustment   `json:"price_adjustments,omitempty"`
+	PriceChangeRequest *PriceChangeRequest    `json:"price_change_request,omitempty"`
+	Error              string                 `json:"error,omitempty"`
+}
+
+// Apply runs the operation on the in-memory phone.
+func (op BulkOperation) Apply(phone *Phone) {
+	switch op.Op {
+	case BulkOpAddTag:
+		for _, tag := range phone.Tags {
+			if tag.ID == op.TagID {
+				return
+			}
+		}
+		phone.Tags = append(phone.Tags, Tag{ID: op.TagID})
+	case BulkOpRemoveTag:
+		tags := make([]Tag, 0, len(phone.Tags))
+		for _, tag := range phone.Tags {
+			if tag.ID != op.TagID {
+				tags = append(tags, tag)
+			}
+		}
+		phone.Tags = tags
+	case BulkOpSetPublishedAt:
+		phone.PublishedAt = op.PublishedAt
+	case BulkOpAdjustPrice:
+		phone.Price = phone.Price.Percent(100+op.Percent, money.HalfUp)
+	case BulkOpSetPrice:
+		phone.Price = op.Price
+	}
+}
+
+// GetBulkPhoneIDs resolves a selector to the IDs of the phones it matches,
+// locking the rows for the transaction.
+func GetBulkPhoneIDs(tx database.TxQueryer, selector BulkSelector) ([]int, error) {
+	query := `
+    SELECT phones.id FROM phones
+    JOIN brands ON phones.brand_id = brands.id
+    WHERE phones.deleted_at IS NULL
+    `
+	args := []any{}
+	if len(selector.IDs) > 0 {
+		query += " AND phones.id IN (?" + repeatPlaceholder(len(selector.IDs)-1) + ")"
+		for _, id := range selector.IDs {
+			args = append(args, id)
+		}
+	}
+	if selector.BrandID != 0 {
+		query += " AND phones.brand_id = ?"
+		args = append(args, selector.BrandID)
+	}
+	filter, filterArgs, err := PhoneFilterClause(selector.FilterBy, selector.FilterValue)
+	if err != nil {
+		return nil, fmt.Errorf("[GetBulkPhoneIDs]%w", err)
+	}
+	query += " " + filter + " ORDER BY phones.id FOR UPDATE"
+	args = append(args, filterArgs...)
+
+	ids := []int{}
+	if err := tx.Select(&ids, query, args...); err != nil {
+		return nil, fmt.Errorf("[GetBulkPhoneIDs][Select]%w", err)
+	}
+	return ids, nil
+}
+
+func repeatPlaceholder(n int) string {
+	s := ""
+	for i := 0; i < n; i++ {
+		s += ", ?"
+	}
+	return s
+}
+
+// RunBulkEdit applies the operations to every phone through Phone.Update, so
+// each phone gets its price history and price rules, and records a revision
+// per changed phone. It stops at the first failing phone; the caller is
+// expected to roll the transaction back when an error is returned.
+func RunBulkEdit(tx database.TxQueryer, ids []int, ops []BulkOperation, opts BulkOptions) ([]BulkResult, error) {
+	results := make([]BulkResult, 0, len(ids))
+	for _, id := range ids {
+		result, err := bulkEditPhone(tx, id, ops, opts)
+		results = append(results, result)
+		if err != nil {
+			return results, fmt.Errorf("[RunBulkEdit] phone %d: %w", id, err)
+		}
+	}
+	return results, nil
+}
+
+func bulkEditPhone(tx database.TxQueryer, id int, ops []BulkOperation, opts BulkOptions) (BulkResult, error) {
+	result := BulkResult{PhoneID: id, Status: BulkResultUnchanged, Changes: map[string]FieldChange{}}
+
+	before, err := getPhoneForUpdate(tx, id)
+	if err != nil {
+		return failBulkResult(result, err)
+	}
+	result.Name = before.Name
+
+	phone := before
+	phone.Tags = append([]Tag{}, before.Tags...)
+	for _, op := range ops {
+		op.Apply(&phone)
+	}
+	if len(DiffPhones(before, phone)) == 0 {
+		return result, nil
+	}
+
+	result.Status = BulkResultUpdated
+	if RequiresPriceApproval(before.Price, phone.Price, opts.ApprovalThreshold) {
+		request := PriceChangeRequest{
+			PhoneID:     id,
+			OldPrice:    before.Price,
+			NewPrice:    phone.Price,
+			RequestedBy: opts.ActorID,
+		}
+		if err := request.Insert(tx); err != nil {
+			return failBulkResult(result, err)
+		}
+		result.Status = BulkResultPendingApproval
+		result.PriceChangeRequest = &request
+		phone.Price = before.Price
+	}
+
+	phone.ConfirmPriceChange = opts.ConfirmPriceChange
+	err = phone.Update(tx, opts.PriceRules)
+	result.PriceAdjustments = phone.PriceAdjustments
+	if err != nil {
+		return failBulkResult(result, err)
+	}
+	if phone.Price != before.Price {
+		if err := ReplaceInstallments(tx, id, phone.Price); err != nil {
+			return failBulkResult(result, err)
+		}
+	}
+
+	result.Changes = DiffPhones(before, phone)
+	if len(result.Changes) > 0 {
+		revision := PhoneRevision{PhoneID: id, Source: PhoneRevisionSourceBulk, Changes: result.Changes}
+		if opts.ActorID != "" {
+			revision.ActorID = &opts.ActorID
+		}
+		if err := revision.Insert(tx); err != nil {
+			return failBulkResult(result, err)
+		}
+	}
+	return result, nil
+}
+
+func failBulkResult(result BulkResult, err error) (BulkResult, error) {
+	result.Status = BulkResultFailed
+	result.Error = "failed to update phone"
+	if errors.Is(err, pricing.ErrConfirmationRequired) {
+		result.Error = pricing.ErrConfirmationRequired.Error()
+	}
+	return result, err
+}
+
+// WithoutSavedRecords drops what only exists once the edit is saved, for dry
+// runs whose transaction is rolled back: the price change request is shown
+// without the ID and timestamps it never kept.
+func (r BulkResult) WithoutSavedRecords() BulkResult {
+	if r.PriceChangeRequest != nil {
+		request := *r.PriceChangeRequest
+		request.Model = Model{}
+		r.PriceChangeRequest = &request
+	}
+	return r
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+func TestBulkOperationApply(t *testing.T) {
+	published := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	before := Phone{ID: 1, Price: money.FromInt(999), Tags: []Tag{{ID: 1}, {ID: 2}}}
+
+	phone := before
+	phone.Tags = append([]Tag{}, before.Tags...)
+	for _, op := range []BulkOperation{
+		{Op: BulkOpAddTag, TagID: 3},
+		{Op: BulkOpAddTag, TagID: 3},
+		{Op: BulkOpRemoveTag, TagID: 1},
+		{Op: BulkOpSetPublishedAt, PublishedAt: &published},
+		{Op: BulkOpAdjustPrice, Percent: 5},
+	} {
+		op.Apply(&phone)
+	}
+
+	if phone.Price != money.FromCents(104895) {
+		t.Errorf("price: want %v; got %v", money.FromCents(104895), phone.Price)
+	}
+	if ids := tagIDs(phone.Tags); !sameInts(ids, []int{2, 3}) {
+		t.Errorf("tags: want %v; got %v", []int{2, 3}, ids)
+	}
+
+	changes := DiffPhones(before, phone)
+	for _, field := range []string{"price", "tags", "published_at"} {
+		if _, ok := changes[field]; !ok {
+			t.Errorf("want %s in changes; got %+v", field, changes)
+		}
+	}
+	if len(changes) != 3 {
+		t.Errorf("want 3 changes; got %+v", changes)
+	}
+	if ids := tagIDs(before.Tags); !sameInts(ids, []int{1, 2}) {
+		t.Errorf("original tags modified: %v", ids)
+	}
+}
@@ -0,0 +1,223 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+const MaxComparedPhones = 4
+
+type PhoneComparison struct {
+	Phones     []ComparedPhone  `json:"phones"`
+	Specs      []SpecComparison `json:"specs"`
+	SharedTags []Tag            `json:"shared_tags"`
+}
+
+type ComparedPhone struct {
+	ID                  int                `json:"id"`
+	Name                string             `json:"name"`
+	BrandID             int                `json:"brand_id"`
+	BrandName           string             `json:"brand_name"`
+	Price               money.Amount       `json:"price"`
+	CheapestInstallment *InstallmentOption `json:"cheapest_installment"`
+	Tags                []Tag              `json:"tags"`
+	DisplayPrices       *DisplayPrices     `json:"display_prices,omitempty"`
+}
+
+// SpecComparison is a single row of the comparison matrix. Values are ordered
+// the same way as PhoneComparison.Phones, with nil for phones that do not
+// declare the field.
+type SpecComparison struct {
+	Key       string    `json:"key"`
+	Values    []*string `json:"values"`
+	Different bool      `json:"different"`
+}
+
+type Specification struct {
+	Key   string
+	Value string
+}
+
+var specKeyReplacer = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ParseSpecifications converts the free-text specifications column into
+// normalized key/value pairs. Both a JSON object and "Key: Value" (or
+// "Key=Value") lines are accepted. Keys are lowercased and snake-cased so the
+// same field written differently across phones ends up in the same row.
+func ParseSpecifications(raw string) []Specification {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(raw, "{") {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(raw), &obj); err == nil {
+			var specs []Specification
+			flattenSpecifications("", obj, &specs)
+			return specs
+		}
+	}
+
+	var specs []Specification
+	for _, line := range strings.Split(raw, "\n") {
+		idx := strings.IndexAny(line, ":=")
+		if idx <= 0 {
+			continue
+		}
+		key := normalizeSpecKey(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+		if key == "" || value == "" {
+			continue
+		}
+		specs = append(specs, Specification{Key: key, Value: value})
+	}
+	return specs
+}
+
+func flattenSpecifications(prefix string, obj map[string]any, specs *[]Specification) {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	// encoding/json does not preserve key order, keep the output stable
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := normalizeSpecKey(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := obj[k].(type) {
+		case map[string]any:
+			flattenSpecifications(key, v, specs)
+		case nil:
+			continue
+		case []any:
+			parts := make([]string, 0, len(v))
+			for _, item := range v {
+				parts = append(parts, fmt.Sprint(item))
+			}
+			*specs = append(*specs, Specification{Key: key, Value: strings.Join(parts, ", ")})
+		default:
+			*specs = append(*specs, Specification{Key: key, Value: fmt.Sprint(v)})
+		}
+	}
+}
+
+func normalizeSpecKey(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	return strings.Trim(specKeyReplacer.ReplaceAllString(key, "_"), "_")
+}
+
+func normalizeSpecValue(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+// Matches reports whether both specifications describe the same field with
+// the same value, ignoring case and spacing differences.
+func (s Specification) Matches(o Specification) bool {
+	return s.Key == o.Key && normalizeSpecValue(s.Value) == normalizeSpecValue(o.Value)
+}
+
+// ComparePhones builds the comparison matrix for the given phones. The
+// installments map is keyed by phone ID and may omit phones without any
+// installment record.
+func ComparePhones(phones []Phone, installments map[int]*Installment) PhoneComparison {
+	comparison := PhoneComparison{
+		Phones:     make([]ComparedPhone, 0, len(phones)),
+		Specs:      []SpecComparison{},
+		SharedTags: []Tag{},
+	}
+
+	rows := map[string]*SpecComparison{}
+	var order []string
+	for i, phone := range phones {
+		compared := ComparedPhone{
+			ID:            phone.ID,
+			Name:          phone.Name,
+			BrandID:       phone.BrandID,
+			BrandName:     phone.BrandName,
+			Price:         phone.Price,
+			Tags:          phone.Tags,
+			DisplayPrices: phone.DisplayPrices,
+		}
+		if installment, ok := installments[phone.ID]; ok && installment != nil {
+			if cheapest, found := installment.Cheapest(); found {
+				compared.CheapestInstallment = &cheapest
+			}
+		}
+		comparison.Phones = append(comparison.Phones, compared)
+
+		for _, spec := range ParseSpecifications(phone.Specifications) {
+			row, ok := rows[spec.Key]
+			if !ok {
+				row = &SpecComparison{Key: spec.Key, Values: make([]*string, len(phones))}
+				rows[spec.Key] = row
+				order = append(order, spec.Key)
+			}
+			if row.Values[i] == nil {
+				value := spec.Value
+				row.Values[i] = &value
+			}
+		}
+	}
+
+	for _, key := range order {
+		row := rows[key]
+		row.Different = specValuesDiffer(row.Values)
+		comparison.Specs = append(comparison.Specs, *row)
+	}
+
+	comparison.SharedTags = sharedTags(phones)
+	return comparison
+}
+
+func specValuesDiffer(values []*string) bool {
+	var first *string
+	for i, v := range values {
+		if i == 0 {
+			first = v
+			continue
+		}
+		if (first == nil) != (v == nil) {
+			return true
+		}
+		if first != nil && normalizeSpecValue(*first) != normalizeSpecValue(*v) {
+			return true
+		}
+	}
+	return false
+}
+
+func sharedTags(phones []Phone) []Tag {
+	shared := []Tag{}
+	if len(phones) == 0 {
+		return shared
+	}
+
+	counts := map[int]int{}
+	for _, phone := range phones {
+		seen := map[int]bool{}
+		for _, tag := range phone.Tags {
+			if seen[tag.ID] {
+				continue
+			}
+			seen[tag.ID] = true
+			counts[tag.ID]++
+		}
+	}
+
+	for _, tag := range phones[0].Tags {
+		if counts[tag.ID] == len(phones) {
+			shared = append(shared, tag)
+			counts[tag.ID] = 0
+		}
+	}
+	return shared
+}
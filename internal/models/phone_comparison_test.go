@@ -0,0 +1,94 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+func TestParseSpecifications(t *testing.T) {
+	t.Run("parses key value lines with normalized keys", func(t *testing.T) {
+		specs := ParseSpecifications("Screen Size: 6.1 inch\nRAM=8GB\nno separator here\n  Battery (mAh) : 4000 ")
+
+		want := []Specification{
+			{Key: "screen_size", Value: "6.1 inch"},
+			{Key: "ram", Value: "8GB"},
+			{Key: "battery_mah", Value: "4000"},
+		}
+		if len(specs) != len(want) {
+			t.Fatalf("want %v; got %v", want, specs)
+		}
+		for i := range want {
+			if specs[i] != want[i] {
+				t.Errorf("want %v; got %v", want[i], specs[i])
+			}
+		}
+	})
+
+	t.Run("parses and flattens JSON objects", func(t *testing.T) {
+		specs := ParseSpecifications(`{"Display": {"Size": "6.1 inch"}, "RAM": 8, "Colors": ["black", "white"]}`)
+
+		want := []Specification{
+			{Key: "colors", Value: "black, white"},
+			{Key: "display.size", Value: "6.1 inch"},
+			{Key: "ram", Value: "8"},
+		}
+		if len(specs) != len(want) {
+			t.Fatalf("want %v; got %v", want, specs)
+		}
+		for i := range want {
+			if specs[i] != want[i] {
+				t.Errorf("want %v; got %v", want[i], specs[i])
+			}
+		}
+	})
+}
+
+func TestComparePhones(t *testing.T) {
+	phones := []Phone{
+		{ID: 1, Specifications: "RAM: 8GB\nScreen: 6.1 inch", Price: money.FromInt(30000), Tags: []Tag{{ID: 1, Name: "smartphone"}, {ID: 5, Name: "new-arrival"}}},
+		{ID: 2, Specifications: "ram: 8gb\nScreen: 6.7 inch\nStylus: yes", Price: money.FromInt(36000), Tags: []Tag{{ID: 1, Name: "smartphone"}}},
+	}
+	installments := map[int]*Installment{
+		1: {PhoneID: 1, ThreeMonths: money.FromInt(10000), SixMonths: money.FromInt(5000), TwelveMonths: money.FromInt(2500)},
+	}
+
+	comparison := ComparePhones(phones, installments)
+
+	t.Run("highlights only differing rows", func(t *testing.T) {
+		different := map[string]bool{}
+		for _, row := range comparison.Specs {
+			different[row.Key] = row.Different
+		}
+		want := map[string]bool{"ram": false, "screen": true, "stylus": true}
+		for k, v := range want {
+			if different[k] != v {
+				t.Errorf("%s: want %v; got %v", k, v, different[k])
+			}
+		}
+	})
+
+	t.Run("leaves missing values empty", func(t *testing.T) {
+		for _, row := range comparison.Specs {
+			if row.Key == "stylus" && row.Values[0] != nil {
+				t.Errorf("want %v; got %v", nil, *row.Values[0])
+			}
+		}
+	})
+
+	t.Run("returns cheapest installment when available", func(t *testing.T) {
+		cheapest := comparison.Phones[0].CheapestInstallment
+		if cheapest == nil || cheapest.Months != 12 || cheapest.MonthlyAmount != money.FromInt(2500) {
+			t.Errorf("want %v; got %v", InstallmentOption{Months: 12, MonthlyAmount: money.FromInt(2500)}, cheapest)
+		}
+		if comparison.Phones[1].CheapestInstallment != nil {
+			t.Errorf("want %v; got %v", nil, comparison.Phones[1].CheapestInstallment)
+		}
+	})
+
+	t.Run("returns tags shared by every phone", func(t *testing.T) {
+		if len(comparison.SharedTags) != 1 || comparison.SharedTags[0].ID != 1 {
+			t.Errorf("want %v; got %v", []Tag{{ID: 1, Name: "smartphone"}}, comparison.SharedTags)
+		}
+	})
+}
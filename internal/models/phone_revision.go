@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+)
+
+const PhoneRevisionSourceBulk = "bulk"
+
+// FieldChange is the value of a phone field before and after a revision.
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type PhoneRevision struct {
+	ID        int                    `db:"id" json:"id"`
+	PhoneID   int                    `db:"phone_id" json:"phone_id"`
+	Source    string                 `db:"source" json:"source"`
+	ActorID   *string                `db:"actor_id" json:"actor_id"`
+	Changes   map[string]FieldChange `db:"-" json:"changes"`
+	CreatedAt time.Time              `db:"created_at" json:"created_at"`
+}
+
+func (r *PhoneRevision) Insert(tx database.TxQueryer) error {
+	changes, err := json.Marshal(r.Changes)
+	if err != nil {
+		return fmt.Errorf("[PhoneRevision.Insert][Marshal]%w", err)
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO phone_revisions (phone_id, source, actor_id, changes) VALUES (?, ?, ?, ?)",
+		r.PhoneID, r.Source, r.ActorID, changes,
+	)
+	if err != nil {
+		return fmt.Errorf("[PhoneRevision.Insert][Exec]%w", err)
+	}
+	return nil
+}
+
+// DiffPhones lists the editable fields that differ between two versions of a
+// phone.
+func DiffPhones(before, after Phone) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	if before.Name != after.Name {
+		changes["name"] = FieldChange{before.Name, after.Name}
+	}
+	if before.BrandID != after.BrandID {
+		changes["brand_id"] = FieldChange{before.BrandID, after.BrandID}
+	}
+	if before.Specifications != after.Specifications {
+		changes["specifications"] = FieldChange{before.Specifications, after.Specifications}
+	}
+	if before.Price != after.Price {
+		changes["price"] = FieldChange{before.Price, after.Price}
+	}
+	if !sameTime(before.PublishedAt, after.PublishedAt) {
+		changes["published_at"] = FieldChange{before.PublishedAt, after.PublishedAt}
+	}
+	if beforeTags, afterTags := tagIDs(before.Tags), tagIDs(after.Tags); !sameInts(beforeTags, afterTags) {
+		changes["tags"] = FieldChange{beforeTags, afterTags}
+	}
+	return changes
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+func tagIDs(tags []Tag) []int {
+	ids := make([]int, 0, len(tags))
+	for _, t := range tags {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func sameInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
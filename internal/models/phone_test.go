@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+func TestCalculateInstallments(t *testing.T) {
+	price := money.FromInt(10000)
+	i := CalculateInstallments(price)
+
+	plans := []struct {
+		months         int64
+		first, monthly money.Amount
+	}{
+		{3, i.ThreeMonthsFirst, i.ThreeMonths},
+		{6, i.SixMonthsFirst, i.SixMonths},
+		{12, i.TwelveMonthsFirst, i.TwelveMonths},
+	}
+	for _, plan := range plans {
+		if total := plan.first + plan.monthly*money.Amount(plan.months-1); total != price {
+			t.Errorf("%d months: want a total of %v; got %v", plan.months, price, total)
+		}
+	}
+	if i.ThreeMonths != money.FromCents(333333) || i.ThreeMonthsFirst != money.FromCents(333334) {
+		t.Errorf("want 3333.34 then 3333.33; got %v then %v", i.ThreeMonthsFirst, i.ThreeMonths)
+	}
+}
+
+func TestPhoneIsPublished(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		phone Phone
+		want  bool
+	}{
+		{"published", Phone{PublishedAt: &past}, true},
+		{"published now", Phone{PublishedAt: &now}, true},
+		{"never published", Phone{}, false},
+		{"published later", Phone{PublishedAt: &future}, false},
+		{"deleted", Phone{PublishedAt: &past, DeletedAt: &past}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.phone.IsPublished(now); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
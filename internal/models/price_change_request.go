@@ -0,0 +1,214 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/pricing"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+	"gopkg.in/guregu/null.v4"
+)
+
+var ErrPriceChangeRequestStale = errors.New("price change request: phone price changed since the request")
+
+type PriceChangeRequest struct {
+	Model
+	PhoneID int `json:"phone_id" db:"phone_id"`
+	// ScheduledPriceChangeID is set when the request approves a schedule
+	// rather than an immediate price change.
+	ScheduledPriceChangeID null.Int     `json:"scheduled_price_change_id" db:"scheduled_price_change_id"`
+	OldPrice               money.Amount `json:"old_price" db:"old_price"`
+	NewPrice               money.Amount `json:"new_price" db:"new_price"`
+	Status                 string       `json:"status" db:"status"`
+	RequestedBy            string       `json:"requested_by" db:"requested_by"`
+	ReviewedBy             null.String  `json:"reviewed_by" db:"reviewed_by"`
+	ReviewNote             null.String  `json:"review_note" db:"review_note"`
+	ReviewedAt             null.Int     `json:"reviewed_at" db:"reviewed_at"`
+}
+
+// RequiresPriceApproval reports whether moving from oldPrice to newPrice is
+// large enough to need a second admin. A zero threshold disables approvals.
+func RequiresPriceApproval(oldPrice, newPrice money.Amount, thresholdPercent float64) bool {
+	if thresholdPercent <= 0 {
+		return false
+	}
+	return pricing.ExceedsPercent(newPrice, oldPrice, thresholdPercent)
+}
+
+func (pcr *PriceChangeRequest) Insert(db database.TxQueryer) error {
+	pcr.BeforeInsert("price_change_requests")
+	pcr.Status = TransactionRequestPending
+
+	q := `
+		INSERT INTO price_change_requests
+		(id, phone_id, scheduled_price_change_id, old_price, new_price, status, requested_by, created_at, updated_at)
+		VALUES
+		(:id, :phone_id, :scheduled_price_change_id, :old_price, :new_price, :status, :requested_by, :created_at, :updated_at)
+	`
+	_, err := db.NamedExec(q, pcr)
+	if err != nil {
+		return fmt.Errorf("[pcr.Insert][NamedExec]%w", err)
+	}
+	return nil
+}
+
+func (pcr *PriceChangeRequest) Update(db database.TxQueryer) error {
+	pcr.BeforeUpdate()
+	q := `
+		UPDATE price_change_requests SET
+			status = :status,
+			reviewed_by = :reviewed_by,
+			review_note = :review_note,
+			reviewed_at = :reviewed_at,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+	_, err := db.NamedExec(q, pcr)
+	if err != nil {
+		return fmt.Errorf("[pcr.Update][NamedExec]%w", err)
+	}
+	return nil
+}
+
+// Approve applies the requested price through Phone.Update, or hands the
+// approved schedule over to the scheduler. The request is refused with
+// ErrPriceChangeRequestStale when the phone price moved since it was created,
+// so an approver never overwrites a newer price.
+func (pcr *PriceChangeRequest) Approve(tx database.TxQueryer, reviewerID string, note string, rules []pricing.Rule) error {
+	phone, err := getPhoneForUpdate(tx, pcr.PhoneID)
+	if err != nil {
+		return fmt.Errorf("[pcr.Approve]%w", err)
+	}
+	if phone.Price != pcr.OldPrice {
+		return ErrPriceChangeRequestStale
+	}
+
+	if pcr.ScheduledPriceChangeID.Valid {
+		if err := pcr.setScheduleStatus(tx, ScheduledPriceChangePending); err != nil {
+			return fmt.Errorf("[pcr.Approve]%w", err)
+		}
+		if err := pcr.review(tx, TransactionRequestApproved, reviewerID, note); err != nil {
+			return fmt.Errorf("[pcr.Approve]%w", err)
+		}
+		return nil
+	}
+
+	phone.Price = pcr.NewPrice
+	// The second admin is the confirmation max step rules ask for
+	phone.ConfirmPriceChange = true
+	if err := phone.Update(tx, rules); err != nil {
+		return fmt.Errorf("[pcr.Approve]%w", err)
+	}
+	if err := ReplaceInstallments(tx, phone.ID, phone.Price); err != nil {
+		return fmt.Errorf("[pcr.Approve]%w", err)
+	}
+
+	if err := pcr.review(tx, TransactionRequestApproved, reviewerID, note); err != nil {
+		return fmt.Errorf("[pcr.Approve]%w", err)
+	}
+	return nil
+}
+
+// Reject leaves the price as it is, a schedule waiting for the request is
+// cancelled.
+func (pcr *PriceChangeRequest) Reject(tx database.TxQueryer, reviewerID string, note string) error {
+	if pcr.ScheduledPriceChangeID.Valid {
+		if err := pcr.setScheduleStatus(tx, ScheduledPriceChangeCancelled); err != nil {
+			return fmt.Errorf("[pcr.Reject]%w", err)
+		}
+	}
+	if err := pcr.review(tx, TransactionRequestRejected, reviewerID, note); err != nil {
+		return fmt.Errorf("[pcr.Reject]%w", err)
+	}
+	return nil
+}
+
+// setScheduleStatus moves the schedule of the request out of
+// ScheduledPriceChangePendingApproval.
+func (pcr *PriceChangeRequest) setScheduleStatus(tx database.TxQueryer, status string) error {
+	schedule, err := LockScheduledPriceChange(tx, int(pcr.ScheduledPriceChangeID.Int64))
+	if err != nil {
+		return fmt.Errorf("[pcr.setScheduleStatus]%w", err)
+	}
+	if schedule.Status != ScheduledPriceChangePendingApproval {
+		return ErrPriceChangeRequestStale
+	}
+	if status == ScheduledPriceChangeCancelled {
+		err = schedule.Cancel(tx)
+	} else {
+		_, err = tx.Exec("UPDATE scheduled_price_changes SET status = ? WHERE id = ?", status, schedule.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("[pcr.setScheduleStatus]%w", err)
+	}
+	return nil
+}
+
+func (pcr *PriceChangeRequest) review(tx database.TxQueryer, status string, reviewerID string, note string) error {
+	pcr.Status = status
+	pcr.ReviewedBy = null.StringFrom(reviewerID)
+	pcr.ReviewNote = null.NewString(note, note != "")
+	pcr.ReviewedAt = null.IntFrom(time.Now().Unix())
+	return pcr.Update(tx)
+}
+
+func GetPriceChangeRequestByID(db database.Queryer, id string) (*PriceChangeRequest, bool, error) {
+	var pcr PriceChangeRequest
+	err := db.Get(&pcr, "SELECT * FROM price_change_requests WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("[GetPriceChangeRequestByID][Get]%w", err)
+	}
+	return &pcr, true, nil
+}
+
+// LockPriceChangeRequest reloads a request and locks it until the transaction
+// ends, so it cannot be reviewed twice.
+func LockPriceChangeRequest(tx database.TxQueryer, id string) (*PriceChangeRequest, bool, error) {
+	var pcr PriceChangeRequest
+	err := tx.Get(&pcr, "SELECT * FROM price_change_requests WHERE id = ? FOR UPDATE", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("[LockPriceChangeRequest][Get]%w", err)
+	}
+	return &pcr, true, nil
+}
+
+func GetPriceChangeRequests(db database.Queryer, status string) ([]PriceChangeRequest, error) {
+	requests := []PriceChangeRequest{}
+	query := "SELECT * FROM price_change_requests"
+	args := []any{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	err := db.Select(&requests, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("[GetPriceChangeRequests][Select]%w", err)
+	}
+	return requests, nil
+}
+
+// GetAdminIDsWithPermission returns the active admins whose role grants the
+// permission.
+func GetAdminIDsWithPermission(db database.Queryer, identifier string) ([]string, error) {
+	ids := []string{}
+	q := `
+	SELECT admins.id FROM admins
+	JOIN authorities ON authorities.role_id = admins.role_id
+	JOIN permissions ON permissions.id = authorities.permission_id
+	WHERE permissions.identifier = ? AND admins.deactivated_at IS NULL
+	`
+	err := db.Select(&ids, q, identifier)
+	if err != nil {
+		return nil, fmt.Errorf("[GetAdminIDsWithPermission][Select]%w", err)
+	}
+	return ids, nil
+}
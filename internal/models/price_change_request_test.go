@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+func TestRequiresPriceApproval(t *testing.T) {
+	tests := []struct {
+		name      string
+		old, new  money.Amount
+		threshold float64
+		want      bool
+	}{
+		{"typo dropping a digit", money.FromInt(39900), money.FromInt(3990), 50, true},
+		{"regular discount", money.FromInt(39900), money.FromInt(35900), 50, false},
+		{"large increase", money.FromInt(10000), money.FromInt(16000), 50, true},
+		{"approvals disabled", money.FromInt(39900), money.FromInt(3990), 0, false},
+		{"no previous price", money.FromInt(0), money.FromInt(3990), 50, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequiresPriceApproval(tt.old, tt.new, tt.threshold); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
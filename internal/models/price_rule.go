@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/pricing"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+type PriceRule struct {
+	ID       int    `db:"id" json:"id"`
+	Name     string `db:"name" json:"name"`
+	RuleType string `db:"rule_type" json:"rule_type"`
+	// Value is the ending of rounding rules and the percentage of the others.
+	Value     money.Amount `db:"value" json:"value"`
+	BrandID   *int         `db:"brand_id" json:"brand_id"`
+	Enabled   bool         `db:"enabled" json:"enabled"`
+	CreatedAt time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time    `db:"updated_at" json:"updated_at"`
+}
+
+func (r *PriceRule) Insert(tx database.TxQueryer) error {
+	query := `
+    INSERT INTO price_rules (name, rule_type, value, brand_id, enabled)
+    VALUES (:name, :rule_type, :value, :brand_id, :enabled);
+  `
+	_, err := tx.NamedExec(query, r)
+	if err != nil {
+		return fmt.Errorf("[PriceRule.Insert][NamedExec]%w", err)
+	}
+	err = tx.QueryRow("SELECT LAST_INSERT_ID()").Scan(&r.ID)
+	if err != nil {
+		return fmt.Errorf("[PriceRule.Insert][QueryRow]%w", err)
+	}
+	return nil
+}
+
+func (r *PriceRule) Update(tx database.TxQueryer) error {
+	query := `
+    UPDATE price_rules SET name = :name, rule_type = :rule_type, value = :value, brand_id = :brand_id, enabled = :enabled, updated_at = CURRENT_TIMESTAMP
+    WHERE id = :id;
+  `
+	_, err := tx.NamedExec(query, r)
+	if err != nil {
+		return fmt.Errorf("[PriceRule.Update][NamedExec]%w", err)
+	}
+	return nil
+}
+
+func (r *PriceRule) Delete(tx database.TxQueryer) error {
+	_, err := tx.Exec("DELETE FROM price_rules WHERE id = ?", r.ID)
+	if err != nil {
+		return fmt.Errorf("[PriceRule.Delete][Exec]%w", err)
+	}
+	return nil
+}
+
+func (r *PriceRule) Rule() pricing.Rule {
+	rule := pricing.Rule{
+		ID:      r.ID,
+		Name:    r.Name,
+		Type:    r.RuleType,
+		BrandID: r.BrandID,
+	}
+	if r.RuleType == pricing.RuleRounding {
+		rule.Ending = r.Value
+	} else {
+		rule.Percent = r.Value.Float64()
+	}
+	return rule
+}
+
+func GetPriceRule(db database.Queryer, id int) (*PriceRule, bool, error) {
+	var rule PriceRule
+	err := db.Get(&rule, "SELECT * FROM price_rules WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("[GetPriceRule][Get]%w", err)
+	}
+	return &rule, true, nil
+}
+
+func GetPriceRules(db database.Queryer) ([]PriceRule, error) {
+	rules := []PriceRule{}
+	err := db.Select(&rules, "SELECT * FROM price_rules ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("[GetPriceRules][Select]%w", err)
+	}
+	return rules, nil
+}
+
+// GetEnabledPricingRules returns the enabled rules in the form the pricing
+// engine expects.
+func GetEnabledPricingRules(tx database.TxQueryer) ([]pricing.Rule, error) {
+	var rules []PriceRule
+	err := tx.Select(&rules, "SELECT * FROM price_rules WHERE enabled = TRUE ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("[GetEnabledPricingRules][Select]%w", err)
+	}
+
+	result := make([]pricing.Rule, 0, len(rules))
+	for _, r := range rules {
+		result = append(result, r.Rule())
+	}
+	return result, nil
+}
+
+// applyPriceRules runs the pricing rules on the phone price. The adjusted
+// price replaces the requested one and the rules that changed it are kept in
+// PriceAdjustments.
+func (p *Phone) applyPriceRules(rules []pricing.Rule, previousPrice *money.Amount) error {
+	result := pricing.Evaluate(rules, pricing.Input{
+		Price:         p.Price,
+		PreviousPrice: previousPrice,
+		CostPrice:     p.CostPrice,
+		BrandID:       p.BrandID,
+		Confirmed:     p.ConfirmPriceChange,
+	})
+	p.PriceAdjustments = result.Adjustments
+	if err := result.Err(); err != nil {
+		return fmt.Errorf("[Phone.applyPriceRules]%w", err)
+	}
+	p.Price = result.Price
+	return nil
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+)
+
+const (
+	RecommendationOverridePin     = "pin"
+	RecommendationOverrideExclude = "exclude"
+)
+
+type RecommendationOverride struct {
+	ID             int       `db:"id" json:"id"`
+	PhoneID        int       `db:"phone_id" json:"phone_id"`
+	RelatedPhoneID int       `db:"related_phone_id" json:"related_phone_id"`
+	Action         string    `db:"action" json:"action"`
+	Position       int       `db:"position" json:"position"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+func (o *RecommendationOverride) Insert(tx database.TxQueryer) error {
+	query := `
+    INSERT INTO phone_recommendation_overrides (phone_id, related_phone_id, action, position)
+    VALUES (:phone_id, :related_phone_id, :action, :position);
+  `
+	_, err := tx.NamedExec(query, o)
+	if err != nil {
+		return fmt.Errorf("[RecommendationOverride.Insert][NamedExec]%w", err)
+	}
+	return nil
+}
+
+func GetRecommendationOverrides(db database.Queryer, phoneID int) ([]RecommendationOverride, error) {
+	overrides := []RecommendationOverride{}
+	query := `
+    SELECT * FROM phone_recommendation_overrides
+    WHERE phone_id = ?
+    ORDER BY action, position, id
+  `
+	err := db.Select(&overrides, query, phoneID)
+	if err != nil {
+		return nil, fmt.Errorf("[GetRecommendationOverrides][Select]%w", err)
+	}
+	return overrides, nil
+}
+
+// ReplaceRecommendationOverrides swaps the whole override set of a phone.
+// Pinned phones keep the order they are given in.
+func ReplaceRecommendationOverrides(tx database.TxQueryer, phoneID int, pinned []int, excluded []int) error {
+	_, err := tx.Exec("DELETE FROM phone_recommendation_overrides WHERE phone_id = ?", phoneID)
+	if err != nil {
+		return fmt.Errorf("[ReplaceRecommendationOverrides][Delete]%w", err)
+	}
+
+	for i, id := range pinned {
+		o := RecommendationOverride{PhoneID: phoneID, RelatedPhoneID: id, Action: RecommendationOverridePin, Position: i}
+		if err := o.Insert(tx); err != nil {
+			return fmt.Errorf("[ReplaceRecommendationOverrides]%w", err)
+		}
+	}
+	for _, id := range excluded {
+		o := RecommendationOverride{PhoneID: phoneID, RelatedPhoneID: id, Action: RecommendationOverrideExclude}
+		if err := o.Insert(tx); err != nil {
+			return fmt.Errorf("[ReplaceRecommendationOverrides]%w", err)
+		}
+	}
+	return nil
+}
+
+// GetPublishedPhones returns every phone visible in the catalog together with
+// its tags, loading the tags in a single query.
+func GetPublishedPhones(db database.Queryer) ([]Phone, error) {
+	phones, err := getPhonesWithTags(db, "phones.deleted_at IS NULL AND phones.published_at IS NOT NULL AND phones.published_at <= NOW()")
+	if err != nil {
+		return nil, fmt.Errorf("[GetPublishedPhones]%w", err)
+	}
+	return phones, nil
+}
+
+// GetCatalogPhones returns every phone that is not deleted, published or not,
+// together with its tags.
+func GetCatalogPhones(db database.Queryer) ([]Phone, error) {
+	phones, err := getPhonesWithTags(db, "phones.deleted_at IS NULL")
+	if err != nil {
+		return nil, fmt.Errorf("[GetCatalogPhones]%w", err)
+	}
+	return phones, nil
+}
+
+func getPhonesWithTags(db database.Queryer, condition string) ([]Phone, error) {
+	phones := []Phone{}
+	query := `
+    SELECT phones.id, phones.name, phones.brand_id, brands.name AS brand_name, phones.specifications, phones.price, phones.cost_price, phones.created_at, phones.updated_at, phones.deleted_at, phones.published_at
+    FROM phones
+    JOIN brands ON phones.brand_id = brands.id
+    WHERE ` + condition + `
+    ORDER BY phones.id
+    `
+	err := db.Select(&phones, query)
+	if err != nil {
+		return nil, fmt.Errorf("[getPhonesWithTags][Select]%w", err)
+	}
+
+	var tags []struct {
+		PhoneID int `db:"phone_id"`
+		Tag
+	}
+	err = db.Select(&tags, "SELECT pt.phone_id, t.id, t.name FROM tags t JOIN phone_tags pt ON t.id = pt.tag_id")
+	if err != nil {
+		return nil, fmt.Errorf("[getPhonesWithTags][SelectTags]%w", err)
+	}
+
+	tagsByPhone := map[int][]Tag{}
+	for _, t := range tags {
+		tagsByPhone[t.PhoneID] = append(tagsByPhone[t.PhoneID], t.Tag)
+	}
+	for i := range phones {
+		phones[i].Tags = tagsByPhone[phones[i].ID]
+	}
+
+	return phones, nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// RequestFingerprint describes where a request came from, as far as the
+// client and the proxies in front of the app tell.
+type RequestFingerprint struct {
+	UserAgent    string `json:"user_agent"`
+	Forwarded    string `json:"forward"`
+	ForwardedFor string `json:"forwarded_for"`
+	RealIP       string `json:"real_ip"`
+}
+
+// Value stores the fingerprint as JSON.
+func (f RequestFingerprint) Value() (driver.Value, error) {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, fmt.Errorf("[RequestFingerprint.Value][Marshal]%w", err)
+	}
+	return string(b), nil
+}
+
+// Scan reads a fingerprint stored as JSON, NULL reads as an empty one.
+func (f *RequestFingerprint) Scan(src any) error {
+	*f = RequestFingerprint{}
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("[RequestFingerprint.Scan] unsupported type %T", src)
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(b, f); err != nil {
+		return fmt.Errorf("[RequestFingerprint.Scan][Unmarshal]%w", err)
+	}
+	return nil
+}
@@ -7,12 +7,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
 )
 
-const (
-	PermissionAdminIndex = "admin::index"
-)
+// ErrRoleInUse is returned when deleting a role still given to admins.
+var ErrRoleInUse = errors.New("role: still given to admins")
+
+// mysqlRowIsReferenced is the error MySQL returns when a foreign key still
+// points to a deleted row.
+const mysqlRowIsReferenced = 1451
 
 type Authorities struct {
 	Model
@@ -20,7 +24,7 @@ type Authorities struct {
 	PermissionID int64  `db:"permission_id" json:"permission_id"`
 }
 
-func (a *Authorities) Insert(db database.Queryer) error {
+func (a *Authorities) Insert(db database.TxQueryer) error {
 	a.BeforeInsert("authorities")
 	q := `
 	INSERT INTO authorities
@@ -35,7 +39,7 @@ func (a *Authorities) Insert(db database.Queryer) error {
 	return nil
 }
 
-func (a *Authorities) Delete(db database.Queryer) error {
+func (a *Authorities) Delete(db database.TxQueryer) error {
 	q := `
 	DELETE FROM authorities
 	WHERE 
@@ -135,7 +139,7 @@ type Role struct {
 	Permissions []Permission `json:"permissions,omitempty" mapstructure:"-"`
 }
 
-func (r *Role) Insert(db database.Queryer) error {
+func (r *Role) Insert(db database.TxQueryer) error {
 	r.BeforeInsert("roles")
 
 	q := `
@@ -151,7 +155,7 @@ func (r *Role) Insert(db database.Queryer) error {
 	return nil
 }
 
-func (r *Role) Update(db database.Queryer) error {
+func (r *Role) Update(db database.TxQueryer) error {
 	r.BeforeUpdate()
 	q := `
 		UPDATE roles SET
@@ -167,17 +171,35 @@ func (r *Role) Update(db database.Queryer) error {
 	return nil
 }
 
-func (r *Role) Delete(db database.Queryer) error {
+func (r *Role) Delete(db database.TxQueryer) error {
 	r.BeforeUpdate()
 
 	q := `DELETE FROM roles WHERE id=:id`
 	_, err := db.NamedExec(q, r)
-	if err != nil {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlRowIsReferenced {
+		return ErrRoleInUse
+	} else if err != nil {
 		return fmt.Errorf("[r.Delete][NamedExec]%w", err)
 	}
 	return nil
 }
 
+// CountRoleAdminsForUpdate counts the admins given a role. The role row stays
+// locked until the transaction ends, so no admin can be given the role
+// meanwhile.
+func CountRoleAdminsForUpdate(tx database.TxQueryer, roleID string) (int, error) {
+	var locked string
+	if err := tx.Get(&locked, "SELECT id FROM roles WHERE id = ? FOR UPDATE", roleID); err != nil {
+		return 0, fmt.Errorf("[CountRoleAdminsForUpdate][LockRole]%w", err)
+	}
+	var count int
+	if err := tx.Get(&count, "SELECT COUNT(*) FROM admins WHERE role_id = ? FOR UPDATE", roleID); err != nil {
+		return 0, fmt.Errorf("[CountRoleAdminsForUpdate][Count]%w", err)
+	}
+	return count, nil
+}
+
 func GetRoleByID(db database.Queryer, id string) (*Role, bool, error) {
 	var role Role
 	err := db.Get(&role, "SELECT * FROM roles WHERE id = ?", id)
@@ -189,7 +211,17 @@ func GetRoleByID(db database.Queryer, id string) (*Role, bool, error) {
 	return &role, true, nil
 }
 
-func (r *Role) LoadPermissions(db database.Queryer) error {
+// RoleNameExists reports whether a role other than exceptID is named name.
+func RoleNameExists(db database.Queryer, name string, exceptID string) (bool, error) {
+	var exists bool
+	err := db.Get(&exists, "SELECT EXISTS(SELECT 1 FROM roles WHERE name = ? AND id <> ?)", name, exceptID)
+	if err != nil {
+		return false, fmt.Errorf("[RoleNameExists][Get]%w", err)
+	}
+	return exists, nil
+}
+
+func (r *Role) LoadPermissions(db database.TxQueryer) error {
 	r.Permissions = nil
 
 	var pivots []Authorities
@@ -213,7 +245,7 @@ func (r *Role) LoadPermissions(db database.Queryer) error {
 	return nil
 }
 
-func (r *Role) AssignPermission(db database.Queryer, permissionID int64) error {
+func (r *Role) AssignPermission(db database.TxQueryer, permissionID int64) error {
 	authorities := Authorities{
 		RoleID:       r.ID,
 		PermissionID: permissionID,
@@ -222,7 +254,7 @@ func (r *Role) AssignPermission(db database.Queryer, permissionID int64) error {
 	return authorities.Insert(db)
 }
 
-func (r *Role) RemovePermission(db database.Queryer, permissionID int64) error {
+func (r *Role) RemovePermission(db database.TxQueryer, permissionID int64) error {
 	authorities := Authorities{
 		RoleID:       r.ID,
 		PermissionID: permissionID,
@@ -231,7 +263,7 @@ func (r *Role) RemovePermission(db database.Queryer, permissionID int64) error {
 	return authorities.Delete(db)
 }
 
-func (r *Role) AdjustAssignedPermissions(db database.Queryer, newPermissions []int64) error {
+func (r *Role) AdjustAssignedPermissions(db database.TxQueryer, newPermissions []int64) error {
 	oldPermissions := make(map[int64]bool)
 	if err := r.LoadPermissions(db); err != nil {
 		return fmt.Errorf("[r.AdjustAssignedPermissions]%w", err)
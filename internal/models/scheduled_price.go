@@ -0,0 +1,307 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/pricing"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+const (
+	// ScheduledPriceChangePendingApproval schedules wait for a second admin
+	// through a PriceChangeRequest before the scheduler picks them up.
+	ScheduledPriceChangePendingApproval = "pending_approval"
+	ScheduledPriceChangePending         = "pending"
+	ScheduledPriceChangeApplied         = "applied"
+	ScheduledPriceChangeReverted        = "reverted"
+	ScheduledPriceChangeCancelled       = "cancelled"
+	// ScheduledPriceChangeConflicted schedules were not reverted because the
+	// price was edited while they were applied.
+	ScheduledPriceChangeConflicted = "conflicted"
+)
+
+type ScheduledPriceChange struct {
+	ID            int           `db:"id" json:"id"`
+	PhoneID       int           `db:"phone_id" json:"phone_id"`
+	Price         money.Amount  `db:"price" json:"price"`
+	PreviousPrice *money.Amount `db:"previous_price" json:"previous_price"`
+	// AppliedPrice is the price the phone got, Price after the price rules.
+	AppliedPrice  *money.Amount `db:"applied_price" json:"applied_price"`
+	EffectiveFrom time.Time     `db:"effective_from" json:"effective_from"`
+	RevertAt      *time.Time    `db:"revert_at" json:"revert_at"`
+	Status        string        `db:"status" json:"status"`
+	AppliedAt     *time.Time    `db:"applied_at" json:"applied_at"`
+	RevertedAt    *time.Time    `db:"reverted_at" json:"reverted_at"`
+	CancelledAt   *time.Time    `db:"cancelled_at" json:"cancelled_at"`
+	CreatedAt     time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time     `db:"updated_at" json:"updated_at"`
+}
+
+func (s *ScheduledPriceChange) Insert(tx database.TxQueryer) error {
+	if s.Status == "" {
+		s.Status = ScheduledPriceChangePending
+	}
+	query := `
+    INSERT INTO scheduled_price_changes (phone_id, price, effective_from, revert_at, status)
+    VALUES (:phone_id, :price, :effective_from, :revert_at, :status);
+  `
+	_, err := tx.NamedExec(query, s)
+	if err != nil {
+		return fmt.Errorf("[ScheduledPriceChange.Insert][NamedExec]%w", err)
+	}
+	err = tx.QueryRow("SELECT LAST_INSERT_ID()").Scan(&s.ID)
+	if err != nil {
+		return fmt.Errorf("[ScheduledPriceChange.Insert][QueryRow]%w", err)
+	}
+	return nil
+}
+
+func (s *ScheduledPriceChange) Cancel(tx database.TxQueryer) error {
+	now := time.Now()
+	_, err := tx.Exec(
+		"UPDATE scheduled_price_changes SET status = ?, cancelled_at = ? WHERE id = ?",
+		ScheduledPriceChangeCancelled, now, s.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("[ScheduledPriceChange.Cancel][Exec]%w", err)
+	}
+	s.Status = ScheduledPriceChangeCancelled
+	s.CancelledAt = &now
+	return nil
+}
+
+// Until returns the end of the window during which the schedule controls the
+// price. A schedule without revert time only occupies its effective instant.
+func (s *ScheduledPriceChange) Until() time.Time {
+	if s.RevertAt != nil {
+		return *s.RevertAt
+	}
+	return s.EffectiveFrom
+}
+
+// Overlaps reports whether both schedules would control the price of the same
+// phone at the same time.
+func (s *ScheduledPriceChange) Overlaps(o ScheduledPriceChange) bool {
+	if s.PhoneID != o.PhoneID {
+		return false
+	}
+	return !s.EffectiveFrom.After(o.Until()) && !o.EffectiveFrom.After(s.Until())
+}
+
+// Apply sets the scheduled price through Phone.Update, so the change is
+// recorded in the price history and the installments follow the new price.
+func (s *ScheduledPriceChange) Apply(tx database.TxQueryer, now time.Time, rules []pricing.Rule) error {
+	phone, err := getPhoneForUpdate(tx, s.PhoneID)
+	if err != nil {
+		return fmt.Errorf("[ScheduledPriceChange.Apply]%w", err)
+	}
+
+	previous := phone.Price
+	if err := setPhonePrice(tx, &phone, s.Price, rules); err != nil {
+		return fmt.Errorf("[ScheduledPriceChange.Apply]%w", err)
+	}
+
+	// A schedule without revert time is done as soon as it is applied
+	applied := phone.Price
+	_, err = tx.Exec(
+		"UPDATE scheduled_price_changes SET status = ?, previous_price = ?, applied_price = ?, applied_at = ? WHERE id = ?",
+		ScheduledPriceChangeApplied, previous, applied, now, s.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("[ScheduledPriceChange.Apply][Exec]%w", err)
+	}
+	s.Status = ScheduledPriceChangeApplied
+	s.PreviousPrice = &previous
+	s.AppliedPrice = &applied
+	s.AppliedAt = &now
+	return nil
+}
+
+// Revert restores the price the phone had before the schedule was applied.
+// When the price was edited in the meantime the edit wins: the schedule is
+// marked ScheduledPriceChangeConflicted and the price is left alone.
+func (s *ScheduledPriceChange) Revert(tx database.TxQueryer, now time.Time, rules []pricing.Rule) error {
+	if s.PreviousPrice == nil {
+		return errors.New("[ScheduledPriceChange.Revert] schedule has not been applied")
+	}
+
+	phone, err := getPhoneForUpdate(tx, s.PhoneID)
+	if err != nil {
+		return fmt.Errorf("[ScheduledPriceChange.Revert]%w", err)
+	}
+	if !s.StillApplied(phone.Price) {
+		_, err = tx.Exec("UPDATE scheduled_price_changes SET status = ? WHERE id = ?", ScheduledPriceChangeConflicted, s.ID)
+		if err != nil {
+			return fmt.Errorf("[ScheduledPriceChange.Revert][Exec]%w", err)
+		}
+		s.Status = ScheduledPriceChangeConflicted
+		return nil
+	}
+	if err := setPhonePrice(tx, &phone, *s.PreviousPrice, rules); err != nil {
+		return fmt.Errorf("[ScheduledPriceChange.Revert]%w", err)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE scheduled_price_changes SET status = ?, reverted_at = ? WHERE id = ?",
+		ScheduledPriceChangeReverted, now, s.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("[ScheduledPriceChange.Revert][Exec]%w", err)
+	}
+	s.Status = ScheduledPriceChangeReverted
+	s.RevertedAt = &now
+	return nil
+}
+
+// StillApplied reports whether the phone price is the one the schedule set.
+// Schedules applied before AppliedPrice existed compare with Price.
+func (s *ScheduledPriceChange) StillApplied(price money.Amount) bool {
+	if s.AppliedPrice != nil {
+		return price == *s.AppliedPrice
+	}
+	return price == s.Price
+}
+
+func setPhonePrice(tx database.TxQueryer, phone *Phone, price money.Amount, rules []pricing.Rule) error {
+	phone.Price = price
+	phone.PriceChangeReason = PriceChangeScheduled
+	// Staff confirmed the amount when scheduling it
+	phone.ConfirmPriceChange = true
+	if err := phone.Update(tx, rules); err != nil {
+		return fmt.Errorf("[setPhonePrice]%w", err)
+	}
+	if err := ReplaceInstallments(tx, phone.ID, phone.Price); err != nil {
+		return fmt.Errorf("[setPhonePrice]%w", err)
+	}
+	return nil
+}
+
+// LockPhone locks the row of a phone until the transaction ends, for changes
+// that have to be checked against the rest of the phone first.
+func LockPhone(tx database.TxQueryer, id int) error {
+	var locked int
+	if err := tx.Get(&locked, "SELECT id FROM phones WHERE id = ? FOR UPDATE", id); err != nil {
+		return fmt.Errorf("[LockPhone][Get]%w", err)
+	}
+	return nil
+}
+
+// getPhoneForUpdate loads a phone with its tags and locks its row until the
+// transaction ends.
+func getPhoneForUpdate(tx database.TxQueryer, id int) (Phone, error) {
+	phone := Phone{}
+	query := `
+    SELECT phones.id, phones.name, phones.brand_id, brands.name AS brand_name, phones.specifications, phones.price, phones.cost_price, phones.created_at, phones.updated_at, phones.deleted_at, phones.published_at
+    FROM phones
+    JOIN brands ON phones.brand_id = brands.id
+    WHERE phones.id = ?
+    FOR UPDATE
+    `
+	err := tx.Get(&phone, query, id)
+	if err != nil {
+		return Phone{}, fmt.Errorf("[getPhoneForUpdate][Get]%w", err)
+	}
+
+	query = `
+    SELECT t.id, t.name
+    FROM tags t
+    JOIN phone_tags pt ON t.id = pt.tag_id
+    WHERE pt.phone_id = ?
+  `
+	err = tx.Select(&phone.Tags, query, id)
+	if err != nil {
+		return Phone{}, fmt.Errorf("[getPhoneForUpdate][SelectTags]%w", err)
+	}
+	return phone, nil
+}
+
+func GetScheduledPriceChange(db database.Queryer, phoneID int, id int) (*ScheduledPriceChange, bool, error) {
+	var s ScheduledPriceChange
+	err := db.Get(&s, "SELECT * FROM scheduled_price_changes WHERE id = ? AND phone_id = ?", id, phoneID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("[GetScheduledPriceChange][Get]%w", err)
+	}
+	return &s, true, nil
+}
+
+// LockScheduledPriceChange reloads a schedule and locks it until the
+// transaction ends, so concurrent workers cannot apply it twice.
+func LockScheduledPriceChange(tx database.TxQueryer, id int) (ScheduledPriceChange, error) {
+	var s ScheduledPriceChange
+	err := tx.Get(&s, "SELECT * FROM scheduled_price_changes WHERE id = ? FOR UPDATE", id)
+	if err != nil {
+		return ScheduledPriceChange{}, fmt.Errorf("[LockScheduledPriceChange][Get]%w", err)
+	}
+	return s, nil
+}
+
+// GetActiveScheduledPriceChanges returns the schedules of a phone that still
+// have work to do: pending ones, with or without approval, and applied ones
+// waiting for their revert.
+func GetActiveScheduledPriceChanges(db database.TxQueryer, phoneID int) ([]ScheduledPriceChange, error) {
+	schedules := []ScheduledPriceChange{}
+	query := `
+    SELECT * FROM scheduled_price_changes
+    WHERE phone_id = ?
+      AND (status IN (?, ?) OR (status = ? AND revert_at IS NOT NULL AND reverted_at IS NULL))
+    ORDER BY effective_from, id
+  `
+	err := db.Select(&schedules, query, phoneID, ScheduledPriceChangePendingApproval, ScheduledPriceChangePending, ScheduledPriceChangeApplied)
+	if err != nil {
+		return nil, fmt.Errorf("[GetActiveScheduledPriceChanges][Select]%w", err)
+	}
+	return schedules, nil
+}
+
+// CancelScheduledPriceChanges cancels the active schedules of a phone, so the
+// scheduler leaves a deleted phone alone.
+func CancelScheduledPriceChanges(tx database.TxQueryer, phoneID int) error {
+	query := `
+    UPDATE scheduled_price_changes SET status = ?, cancelled_at = ?
+    WHERE phone_id = ?
+      AND (status IN (?, ?) OR (status = ? AND revert_at IS NOT NULL AND reverted_at IS NULL))
+  `
+	_, err := tx.Exec(query, ScheduledPriceChangeCancelled, time.Now(), phoneID, ScheduledPriceChangePendingApproval, ScheduledPriceChangePending, ScheduledPriceChangeApplied)
+	if err != nil {
+		return fmt.Errorf("[CancelScheduledPriceChanges][Exec]%w", err)
+	}
+	return nil
+}
+
+// GetDueScheduledPriceChanges returns the pending schedules whose effective
+// time has passed, oldest first.
+func GetDueScheduledPriceChanges(db database.Queryer, now time.Time) ([]ScheduledPriceChange, error) {
+	schedules := []ScheduledPriceChange{}
+	query := `
+    SELECT * FROM scheduled_price_changes
+    WHERE status = ? AND effective_from <= ?
+    ORDER BY effective_from, id
+  `
+	err := db.Select(&schedules, query, ScheduledPriceChangePending, now)
+	if err != nil {
+		return nil, fmt.Errorf("[GetDueScheduledPriceChanges][Select]%w", err)
+	}
+	return schedules, nil
+}
+
+// GetDueScheduledPriceReverts returns the applied schedules whose revert time
+// has passed, oldest first.
+func GetDueScheduledPriceReverts(db database.Queryer, now time.Time) ([]ScheduledPriceChange, error) {
+	schedules := []ScheduledPriceChange{}
+	query := `
+    SELECT * FROM scheduled_price_changes
+    WHERE status = ? AND revert_at IS NOT NULL AND revert_at <= ?
+    ORDER BY revert_at, id
+  `
+	err := db.Select(&schedules, query, ScheduledPriceChangeApplied, now)
+	if err != nil {
+		return nil, fmt.Errorf("[GetDueScheduledPriceReverts][Select]%w", err)
+	}
+	return schedules, nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+func TestScheduledPriceChangeOverlaps(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)
+	}
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	promo := ScheduledPriceChange{PhoneID: 1, EffectiveFrom: at(0), RevertAt: ptr(at(6))}
+
+	tests := []struct {
+		name  string
+		other ScheduledPriceChange
+		want  bool
+	}{
+		{"change inside the window", ScheduledPriceChange{PhoneID: 1, EffectiveFrom: at(3)}, true},
+		{"change at the revert time", ScheduledPriceChange{PhoneID: 1, EffectiveFrom: at(6)}, true},
+		{"change after the window", ScheduledPriceChange{PhoneID: 1, EffectiveFrom: at(7)}, false},
+		{"window around the promo", ScheduledPriceChange{PhoneID: 1, EffectiveFrom: at(-1), RevertAt: ptr(at(8))}, true},
+		{"other phone", ScheduledPriceChange{PhoneID: 2, EffectiveFrom: at(3)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := promo.Overlaps(tt.other); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+			if got := tt.other.Overlaps(promo); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestScheduledPriceChangeStillApplied(t *testing.T) {
+	rounded := money.FromInt(999)
+	tests := []struct {
+		name     string
+		schedule ScheduledPriceChange
+		price    money.Amount
+		want     bool
+	}{
+		{"applied price untouched", ScheduledPriceChange{Price: money.FromInt(1000), AppliedPrice: &rounded}, rounded, true},
+		{"edited during the window", ScheduledPriceChange{Price: money.FromInt(1000), AppliedPrice: &rounded}, money.FromInt(1200), false},
+		{"applied before applied_price", ScheduledPriceChange{Price: money.FromInt(1000)}, money.FromInt(1000), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.StillApplied(tt.price); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
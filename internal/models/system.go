@@ -2,8 +2,12 @@ package models
 
 import (
 	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/constants"
@@ -18,13 +22,19 @@ import (
 
 type System struct {
 	Model
-	Name           string      `json:"name"`
-	URL            string      `json:"url"`
-	SecretKey      null.String `json:"secret" db:"secret_key"`
-	PlainSecretKey null.String `json:"-" db:"-"`
+	Name      string      `json:"name"`
+	URL       string      `json:"url"`
+	SecretKey null.String `json:"-" db:"secret_key"`
+	// PreviousSecretKey keeps working until PreviousSecretExpiresAt, in unix
+	// seconds, so that a rotated secret can be rolled out without downtime.
+	PreviousSecretKey       null.String `json:"-" db:"previous_secret_key"`
+	PreviousSecretExpiresAt null.Int    `json:"previous_secret_expires_at" db:"previous_secret_expires_at"`
+	RevokedAt               null.Int    `json:"revoked_at" db:"revoked_at"`
+	Scopes                  []string    `json:"scopes,omitempty" db:"-"`
+	PlainSecretKey          null.String `json:"-" db:"-"`
 }
 
-func (s *System) Insert(db database.Queryer) error {
+func (s *System) Insert(db database.TxQueryer) error {
 	id := ulid.Make()
 	s.ID = "systems:" + id.String()
 
@@ -33,15 +43,12 @@ func (s *System) Insert(db database.Queryer) error {
 	s.Model.UpdatedAt = now
 
 	if s.PlainSecretKey.Valid {
-		h := sha256.New()
-		h.Write([]byte(s.PlainSecretKey.ValueOrZero()))
-		hashed := hex.EncodeToString(h.Sum(nil))
-		s.SecretKey = null.StringFrom(hashed)
+		s.SecretKey = null.StringFrom(HashSecretKey(s.PlainSecretKey.ValueOrZero()))
 		s.PlainSecretKey = null.String{}
 	}
 
-	q := "INSERT INTO systems (id, name, url, secret_key, created_at, updated_at) " +
-		"VALUES (:id, :name, :url, :secret_key, :created_at, :updated_at)"
+	q := "INSERT INTO systems (id, name, url, secret_key, previous_secret_key, previous_secret_expires_at, revoked_at, created_at, updated_at) " +
+		"VALUES (:id, :name, :url, :secret_key, :previous_secret_key, :previous_secret_expires_at, :revoked_at, :created_at, :updated_at)"
 	_, err := db.NamedExec(q, s)
 	if err != nil {
 		return fmt.Errorf("[s.Insert][NamedExec]%w", err)
@@ -49,14 +56,11 @@ func (s *System) Insert(db database.Queryer) error {
 	return nil
 }
 
-func (s *System) Update(db database.Queryer) error {
+func (s *System) Update(db database.TxQueryer) error {
 	s.UpdatedAt = time.Now().Unix()
 
 	if s.PlainSecretKey.Valid {
-		h := sha256.New()
-		h.Write([]byte(s.PlainSecretKey.ValueOrZero()))
-		hashed := hex.EncodeToString(h.Sum(nil))
-		s.SecretKey = null.StringFrom(hashed)
+		s.SecretKey = null.StringFrom(HashSecretKey(s.PlainSecretKey.ValueOrZero()))
 		s.PlainSecretKey = null.String{}
 	}
 
@@ -65,6 +69,9 @@ func (s *System) Update(db database.Queryer) error {
 			name = :name,
 			url = :url,
 			secret_key = :secret_key,
+			previous_secret_key = :previous_secret_key,
+			previous_secret_expires_at = :previous_secret_expires_at,
+			revoked_at = :revoked_at,
 			created_at = :created_at,
 			updated_at = :updated_at
 		WHERE id = :id
@@ -76,6 +83,76 @@ func (s *System) Update(db database.Queryer) error {
 	return nil
 }
 
+func GetSystemByID(db database.Queryer, id string) (*System, bool, error) {
+	var system System
+	err := db.Get(&system, "SELECT * FROM systems WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("[GetSystemByID][Get]%w", err)
+	}
+	return &system, true, nil
+}
+
+func GetSystems(db database.Queryer) ([]System, error) {
+	systems := []System{}
+	err := db.Select(&systems, "SELECT * FROM systems ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("[GetSystems][Select]%w", err)
+	}
+	return systems, nil
+}
+
+// HashSecretKey is how secrets are stored, the hex encoded sha256 sum.
+func HashSecretKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifySecretKey compares a presented secret with the stored hashes in
+// constant time. The previous secret is accepted until its grace period
+// ends, revoked systems accept none.
+func (s *System) VerifySecretKey(plain string) bool {
+	if s.IsRevoked() {
+		return false
+	}
+	hashed := []byte(HashSecretKey(plain))
+
+	current := s.SecretKey.Valid && subtle.ConstantTimeCompare(hashed, []byte(s.SecretKey.String)) == 1
+	previous := s.PreviousSecretKey.Valid && subtle.ConstantTimeCompare(hashed, []byte(s.PreviousSecretKey.String)) == 1 &&
+		time.Now().Unix() < s.PreviousSecretExpiresAt.ValueOrZero()
+	return current || previous
+}
+
+// RotateSecretKey replaces the secret with a new one, returned in plain text
+// and only stored hashed once the system is updated. The old secret keeps
+// working for grace, which may be zero.
+func (s *System) RotateSecretKey(grace time.Duration) string {
+	if grace > 0 && s.SecretKey.Valid {
+		s.PreviousSecretKey = s.SecretKey
+		s.PreviousSecretExpiresAt = null.IntFrom(time.Now().Add(grace).Unix())
+	} else {
+		s.PreviousSecretKey = null.String{}
+		s.PreviousSecretExpiresAt = null.Int{}
+	}
+
+	plain := GenerateSecretKey()
+	s.PlainSecretKey = null.StringFrom(plain)
+	return plain
+}
+
+// Revoke stops the system from getting new tokens, the tokens it holds have
+// to be revoked separately.
+func (s *System) Revoke() {
+	if !s.IsRevoked() {
+		s.RevokedAt = null.IntFrom(time.Now().Unix())
+	}
+}
+
+func (s *System) IsRevoked() bool {
+	return s.RevokedAt.Valid
+}
+
 func GenerateSecretKey() string {
 	return random.GenerateString(
 		64,
@@ -102,6 +179,19 @@ func (s *System) IssueAccessToken(exp time.Time) (jwt.Token, error) {
 	return token, nil
 }
 
+// IssueScopedAccessToken issues an access token restricted to scopes, kept
+// space-delimited in the scope claim as in RFC 9068.
+func (s *System) IssueScopedAccessToken(exp time.Time, scopes []string) (jwt.Token, error) {
+	token, err := s.IssueAccessToken(exp)
+	if err != nil {
+		return nil, err
+	}
+	if err := token.Set("scope", strings.Join(scopes, " ")); err != nil {
+		return nil, fmt.Errorf("[s.IssueScopedAccessToken][Set]%w", err)
+	}
+	return token, nil
+}
+
 type SystemAccessToken struct {
 	Model
 	SystemID  string    `json:"system_id" db:"system_id"`
@@ -110,7 +200,14 @@ type SystemAccessToken struct {
 }
 
 func (sat *SystemAccessToken) Insert(db database.Queryer) error {
-	sat.BeforeInsert("system_access_tokens")
+	// The ID is the JWT ID of the token, only generated when it is not set
+	if sat.ID == "" {
+		sat.BeforeInsert("system_access_tokens")
+	} else {
+		now := time.Now().Unix()
+		sat.CreatedAt = now
+		sat.UpdatedAt = now
+	}
 
 	q := "INSERT INTO system_access_tokens (id, system_id, expired_at, revoked_at, created_at, updated_at) " +
 		"VALUES (:id, :system_id, :expired_at, :revoked_at, :created_at, :updated_at)"
@@ -138,6 +235,34 @@ func (sat *SystemAccessToken) Update(db database.Queryer) error {
 	return nil
 }
 
+// GetActiveSystemAccessTokens returns the tokens of a system that are
+// neither revoked nor expired.
+func GetActiveSystemAccessTokens(db database.Queryer, systemID string) ([]SystemAccessToken, error) {
+	var tokens []SystemAccessToken
+	err := db.Select(
+		&tokens,
+		"SELECT * FROM system_access_tokens WHERE system_id = ? AND revoked_at IS NULL AND expired_at > NOW()",
+		systemID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("[GetActiveSystemAccessTokens][Select]%w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeSystemAccessTokens revokes the active tokens of a system in the
+// database only, servers pick them up with their revocation list.
+func RevokeSystemAccessTokens(db database.TxQueryer, systemID string) error {
+	_, err := db.Exec(
+		"UPDATE system_access_tokens SET revoked_at = NOW(), updated_at = ? WHERE system_id = ? AND revoked_at IS NULL AND expired_at > NOW()",
+		time.Now().Unix(), systemID,
+	)
+	if err != nil {
+		return fmt.Errorf("[RevokeSystemAccessTokens][Exec]%w", err)
+	}
+	return nil
+}
+
 type UserClient struct {
 	Model
 	Name      string   `json:"name"`
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+)
+
+const (
+	SystemScopeCatalogRead  = "catalog:read"
+	SystemScopeCatalogWrite = "catalog:write"
+	SystemScopePricesRead   = "prices:read"
+)
+
+// SystemScopes lists every scope a system can be granted.
+var SystemScopes = []string{
+	SystemScopeCatalogRead,
+	SystemScopeCatalogWrite,
+	SystemScopePricesRead,
+}
+
+// ScopePermissions are the admin permissions a scope stands for on the routes
+// shared by admins and systems.
+var ScopePermissions = map[string][]string{
+	SystemScopeCatalogWrite: {PermissionPhoneCreate, PermissionPhoneUpdate, PermissionPhoneDelete},
+}
+
+// IsSystemScope reports whether scope is in SystemScopes.
+func IsSystemScope(scope string) bool {
+	for _, s := range SystemScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopesGrantPermission reports whether one of scopes stands for the
+// permission.
+func ScopesGrantPermission(scopes []string, permission string) bool {
+	for _, scope := range scopes {
+		for _, p := range ScopePermissions[scope] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetSystemScopes returns the scopes granted to a system.
+func GetSystemScopes(db database.Queryer, systemID string) ([]string, error) {
+	scopes := []string{}
+	err := db.Select(&scopes, "SELECT scope FROM system_scopes WHERE system_id = ? ORDER BY scope", systemID)
+	if err != nil {
+		return nil, fmt.Errorf("[GetSystemScopes][Select]%w", err)
+	}
+	return scopes, nil
+}
+
+// SetSystemScopes replaces the scopes granted to a system.
+func SetSystemScopes(tx database.TxQueryer, systemID string, scopes []string) error {
+	if _, err := tx.Exec("DELETE FROM system_scopes WHERE system_id = ?", systemID); err != nil {
+		return fmt.Errorf("[SetSystemScopes][Delete]%w", err)
+	}
+	now := time.Now().Unix()
+	for _, scope := range scopes {
+		_, err := tx.Exec("INSERT IGNORE INTO system_scopes (system_id, scope, created_at) VALUES (?, ?, ?)", systemID, scope, now)
+		if err != nil {
+			return fmt.Errorf("[SetSystemScopes][Insert]%w", err)
+		}
+	}
+	return nil
+}
+
+func (s *System) LoadScopes(db database.Queryer) error {
+	scopes, err := GetSystemScopes(db, s.ID)
+	if err != nil {
+		return fmt.Errorf("[s.LoadScopes]%w", err)
+	}
+	s.Scopes = scopes
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestVerifySecretKey(t *testing.T) {
+	system := System{SecretKey: null.StringFrom(HashSecretKey("s3cret"))}
+
+	tests := []struct {
+		name   string
+		system System
+		secret string
+		want   bool
+	}{
+		{"matching secret", system, "s3cret", true},
+		{"other secret", system, "s3cret ", false},
+		{"stored hash as secret", system, system.SecretKey.String, false},
+		{"no secret stored", System{}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.system.VerifySecretKey(tt.secret); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRotateSecretKey(t *testing.T) {
+	system := System{SecretKey: null.StringFrom(HashSecretKey("old"))}
+	secret := system.RotateSecretKey(time.Hour)
+	system.SecretKey = null.StringFrom(HashSecretKey(system.PlainSecretKey.String))
+
+	if !system.VerifySecretKey(secret) {
+		t.Error("want the new secret accepted")
+	}
+	if !system.VerifySecretKey("old") {
+		t.Error("want the old secret accepted during the grace period")
+	}
+
+	system.PreviousSecretExpiresAt = null.IntFrom(time.Now().Add(-time.Second).Unix())
+	if system.VerifySecretKey("old") {
+		t.Error("want the old secret rejected after the grace period")
+	}
+
+	system.RotateSecretKey(0)
+	if system.PreviousSecretKey.Valid {
+		t.Error("want no previous secret without a grace period")
+	}
+
+	system.Revoke()
+	if system.VerifySecretKey(secret) {
+		t.Error("want every secret rejected once revoked")
+	}
+}
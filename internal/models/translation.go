@@ -0,0 +1,165 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+)
+
+const (
+	TranslationPhone = "phone"
+	TranslationTag   = "tag"
+	TranslationBrand = "brand"
+)
+
+// TranslatableFields lists the fields of each entity which can be translated.
+// The stored value is used for locales without a translation.
+var TranslatableFields = map[string][]string{
+	TranslationPhone: {"name", "specifications"},
+	TranslationTag:   {"name"},
+	TranslationBrand: {"name"},
+}
+
+type Translation struct {
+	ID         int       `db:"id" json:"id"`
+	EntityType string    `db:"entity_type" json:"entity_type"`
+	EntityID   int       `db:"entity_id" json:"entity_id"`
+	Locale     string    `db:"locale" json:"locale"`
+	Field      string    `db:"field" json:"field"`
+	Value      string    `db:"value" json:"value"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// IsTranslatable reports whether field of entityType can be translated.
+func IsTranslatable(entityType string, field string) bool {
+	for _, f := range TranslatableFields[entityType] {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Save inserts the translation or replaces the existing value of the field.
+func (t *Translation) Save(tx database.TxQueryer) error {
+	query := `
+    INSERT INTO translations (entity_type, entity_id, locale, field, value)
+    VALUES (:entity_type, :entity_id, :locale, :field, :value)
+    ON DUPLICATE KEY UPDATE value = VALUES(value), updated_at = CURRENT_TIMESTAMP;
+  `
+	_, err := tx.NamedExec(query, t)
+	if err != nil {
+		return fmt.Errorf("[Translation.Save][NamedExec]%w", err)
+	}
+	return nil
+}
+
+// DeleteTranslations removes the translations of an entity in a locale, only
+// of the given fields when any is given.
+func DeleteTranslations(tx database.TxQueryer, entityType string, entityID int, locale string, fields ...string) error {
+	query := "DELETE FROM translations WHERE entity_type = ? AND entity_id = ? AND locale = ?"
+	args := []any{entityType, entityID, locale}
+	if len(fields) > 0 {
+		query += " AND field IN (?" + strings.Repeat(", ?", len(fields)-1) + ")"
+		for _, f := range fields {
+			args = append(args, f)
+		}
+	}
+
+	_, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("[DeleteTranslations][Exec]%w", err)
+	}
+	return nil
+}
+
+func GetTranslations(db database.Queryer, entityType string, entityID int) ([]Translation, error) {
+	translations := []Translation{}
+	err := db.Select(
+		&translations,
+		"SELECT * FROM translations WHERE entity_type = ? AND entity_id = ? ORDER BY locale, field",
+		entityType, entityID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("[GetTranslations][Select]%w", err)
+	}
+	return translations, nil
+}
+
+type translationKey struct {
+	EntityType string
+	EntityID   int
+	Field      string
+}
+
+// TranslatePhones replaces the names and specifications of the phones, and
+// the names of their brand and tags, with their translation in locale.
+// Fields without a translation keep the stored value.
+func TranslatePhones(db database.Queryer, phones []Phone, locale string) error {
+	if len(phones) == 0 {
+		return nil
+	}
+
+	ids := map[string][]int{}
+	for _, p := range phones {
+		ids[TranslationPhone] = append(ids[TranslationPhone], p.ID)
+		ids[TranslationBrand] = append(ids[TranslationBrand], p.BrandID)
+		for _, tag := range p.Tags {
+			ids[TranslationTag] = append(ids[TranslationTag], tag.ID)
+		}
+	}
+
+	var conditions []string
+	args := []any{locale}
+	for _, entityType := range []string{TranslationPhone, TranslationBrand, TranslationTag} {
+		if len(ids[entityType]) == 0 {
+			continue
+		}
+		conditions = append(conditions, "(entity_type = ? AND entity_id IN (?))")
+		args = append(args, entityType, ids[entityType])
+	}
+
+	query, args, err := sqlx.In(
+		"SELECT entity_type, entity_id, field, value FROM translations WHERE locale = ? AND ("+strings.Join(conditions, " OR ")+")",
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("[TranslatePhones][In]%w", err)
+	}
+	var rows []Translation
+	if err := db.Select(&rows, db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("[TranslatePhones][Select]%w", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	values := make(map[translationKey]string, len(rows))
+	for _, row := range rows {
+		values[translationKey{row.EntityType, row.EntityID, row.Field}] = row.Value
+	}
+	translate := func(entityType string, id int, field string, value *string) {
+		if v, ok := values[translationKey{entityType, id, field}]; ok {
+			*value = v
+		}
+	}
+
+	for i := range phones {
+		p := &phones[i]
+		translate(TranslationPhone, p.ID, "name", &p.Name)
+		translate(TranslationPhone, p.ID, "specifications", &p.Specifications)
+		translate(TranslationBrand, p.BrandID, "name", &p.BrandName)
+		// Tags may be shared with other phones of the list
+		tags := make([]Tag, len(p.Tags))
+		for j, tag := range p.Tags {
+			translate(TranslationTag, tag.ID, "name", &tag.Name)
+			tags[j] = tag
+		}
+		p.Tags = tags
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package authentication
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/cache"
+)
+
+var (
+	ErrAdminDeactivated = errors.New("admin has been deactivated")
+	ErrTokenInvalidated = errors.New("access token was issued before the admin last changed")
+)
+
+// AdminStateExpiration bounds how long a change of an admin made without
+// ForgetAdmin, directly in the database for example, takes to apply.
+const AdminStateExpiration = 5 * time.Minute
+
+// AdminState is what the tokens of an admin are checked against on every
+// request, cached so that it does not cost a query per request.
+type AdminState struct {
+	Exists           bool
+	Deactivated      bool
+	TokensValidAfter int64
+}
+
+func adminStateKey(adminID string) string {
+	return "auth:admin_state_" + adminID
+}
+
+// GetAdminState returns the state of an admin, from the cache when it holds
+// it.
+func (a *Auth) GetAdminState(adminID string) (AdminState, error) {
+	var state AdminState
+	_, err := a.cache.GetValue(adminStateKey(adminID), &state)
+	if err == nil {
+		return state, nil
+	} else if !errors.Is(err, cache.ErrKeyNotFound) {
+		return state, fmt.Errorf("[Auth.GetAdminState][GetValue]%w", err)
+	}
+
+	admin, found, err := models.GetAdminByID(a.db, adminID)
+	if err != nil {
+		return state, fmt.Errorf("[Auth.GetAdminState]%w", err)
+	}
+	if found {
+		state = AdminState{
+			Exists:           true,
+			Deactivated:      admin.IsDeactivated(),
+			TokensValidAfter: admin.TokensValidAfter.ValueOrZero(),
+		}
+	}
+
+	err = a.cache.PutValue(adminStateKey(adminID), state, &cache.Options{Expiration: AdminStateExpiration})
+	if err != nil {
+		return state, fmt.Errorf("[Auth.GetAdminState][PutValue]%w", err)
+	}
+	return state, nil
+}
+
+// ForgetAdmin drops the cached state of an admin, it has to be called after
+// every change of deactivated_at or tokens_valid_after.
+func (a *Auth) ForgetAdmin(adminID string) error {
+	if err := a.cache.Delete(adminStateKey(adminID)); err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+		return fmt.Errorf("[Auth.ForgetAdmin][Delete]%w", err)
+	}
+	return nil
+}
+
+// VerifyAdminToken checks an admin token against the current state of its
+// admin, ErrAdminDeactivated or ErrTokenInvalidated when it no longer holds.
+func (a *Auth) VerifyAdminToken(token jwt.Token) error {
+	state, err := a.GetAdminState(token.Subject())
+	if err != nil {
+		return err
+	}
+	return state.Check(token)
+}
+
+// Check reports whether a token of the admin is still acceptable.
+func (s AdminState) Check(token jwt.Token) error {
+	if !s.Exists || s.Deactivated {
+		return ErrAdminDeactivated
+	}
+	if s.TokensValidAfter > 0 && token.IssuedAt().Unix() <= s.TokensValidAfter {
+		return ErrTokenInvalidated
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package authentication
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+func TestAdminStateCheck(t *testing.T) {
+	issuedAt := time.Unix(1700000000, 0)
+	token, err := jwt.NewBuilder().Subject("admins:1").IssuedAt(issuedAt).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		state AdminState
+		want  error
+	}{
+		{"active", AdminState{Exists: true}, nil},
+		{"issued after the change", AdminState{Exists: true, TokensValidAfter: issuedAt.Unix() - 60}, nil},
+		{"issued the second of the change", AdminState{Exists: true, TokensValidAfter: issuedAt.Unix()}, ErrTokenInvalidated},
+		{"issued before the change", AdminState{Exists: true, TokensValidAfter: issuedAt.Unix() + 1}, ErrTokenInvalidated},
+		{"deactivated", AdminState{Exists: true, Deactivated: true}, ErrAdminDeactivated},
+		{"deleted", AdminState{}, ErrAdminDeactivated},
+	}
+	for _, tt := range tests {
+		if err := tt.state.Check(token); !errors.Is(err, tt.want) {
+			t.Errorf("%s: want %v; got %v", tt.name, tt.want, err)
+		}
+	}
+}
@@ -8,8 +8,8 @@ import (
 
 	"gopkg.in/guregu/null.v4"
 
-	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/pkg/errors"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
@@ -24,7 +24,7 @@ type Auth struct {
 	MobileBEAuth *mobilebe.Client
 	cache        cache.Cache
 	db           database.Queryer
-	verifyKey    jwk.RSAPublicKey
+	verifyKeys   jwk.Set
 }
 
 var (
@@ -34,10 +34,10 @@ var (
 	ErrIncorrectAccountType = errors.New("token is created for other account type")
 )
 
-func (a *Auth) Init(db database.Queryer, cache cache.Cache, verifyKey jwk.RSAPublicKey) *Auth {
+func (a *Auth) Init(db database.Queryer, cache cache.Cache, verifyKeys jwk.Set) *Auth {
 	a.db = db
 	a.cache = cache
-	a.verifyKey = verifyKey
+	a.verifyKeys = verifyKeys
 	return a
 }
 
@@ -50,7 +50,7 @@ func (a *Auth) GetTokenInfo(r *http.Request) (jwt.Token, error) {
 	opts := []jwt.ParseOption{
 		jwt.WithHeaderKey("Authorization"),
 		jwt.WithFormKey("x_access_token"),
-		jwt.WithKey(jwa.RS256, a.verifyKey),
+		VerifyKeySet(a.verifyKeys),
 	}
 
 	t, err := jwt.ParseRequest(r, opts...)
@@ -64,40 +64,52 @@ func (a *Auth) GetTokenInfo(r *http.Request) (jwt.Token, error) {
 func (a *Auth) LoadRevocationList() error {
 	tables := []string{"system_access_tokens", "admin_access_tokens"}
 	for _, table := range tables {
-		q := fmt.Sprintf(`
-			SELECT id, expired_at 
-			FROM %s 
-			WHERE
-				revoked_at IS NOT NULL AND 
-				(expired_at IS NULL OR expired_at > NOW());
-		`, table)
-		rows, err := a.db.Queryx(q)
-		if errors.Is(sql.ErrNoRows, err) {
-			continue
-		} else if err != nil {
-			return err
+		if err := a.loadRevokedTokens(table); err != nil {
+			return fmt.Errorf("[Auth.LoadRevocationList]%w", err)
+		}
+	}
+	return nil
+}
+
+func (a *Auth) loadRevokedTokens(table string) error {
+	q := fmt.Sprintf(`
+		SELECT id, expired_at 
+		FROM %s 
+		WHERE
+			revoked_at IS NOT NULL AND 
+			(expired_at IS NULL OR expired_at > NOW());
+	`, table)
+	rows, err := a.db.Queryx(q)
+	if errors.Is(sql.ErrNoRows, err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("[Auth.loadRevokedTokens][Queryx]%w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token tokenToRevoke
+		if err = rows.StructScan(&token); err != nil {
+			return fmt.Errorf("[Auth.loadRevokedTokens][StructScan]%w", err)
 		}
-		for rows.Next() {
-			var token tokenToRevoke
-			if err = rows.StructScan(&token); err != nil {
-				return err
-			}
-			opt := cache.Options{}
-			if token.ExpiredAt.Valid {
-				opt.Expiration = time.Until(token.ExpiredAt.Time) + time.Hour
-			}
-			err := a.cache.PutValue(fmt.Sprintf("auth:revoked_%s", token.ID), true, &opt)
-			if err != nil {
-				panic(err)
-			}
+		opt := cache.Options{}
+		if token.ExpiredAt.Valid {
+			opt.Expiration = time.Until(token.ExpiredAt.Time) + time.Hour
 		}
+		err := a.cache.PutValue(fmt.Sprintf("auth:revoked_%s", token.ID), true, &opt)
+		if err != nil {
+			return fmt.Errorf("[Auth.loadRevokedTokens][PutValue]%w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("[Auth.loadRevokedTokens][Rows]%w", err)
 	}
 	return nil
 }
 
 func (a *Auth) Verify(tokenStr string, accountType string) (jwt.Token, error) {
 	opts := []jwt.ParseOption{
-		jwt.WithKey(jwa.RS256, a.verifyKey),
+		VerifyKeySet(a.verifyKeys),
 	}
 
 	t, err := jwt.Parse([]byte(tokenStr), opts...)
@@ -148,6 +160,7 @@ func (a *Auth) Revoke(token any) error {
 		token = &t
 	case models.SystemAccessToken:
 		token = &t
+	case *models.AdminAccessToken, *models.SystemAccessToken:
 	default:
 		return assertionError
 	}
@@ -181,3 +194,66 @@ func (a *Auth) Revoke(token any) error {
 	}
 	return a.cache.PutValue(fmt.Sprintf("auth:revoked_%s", tokenID), true, &opt)
 }
+
+// RevokeAdmin revokes every active access token and refresh token of an
+// admin.
+func (a *Auth) RevokeAdmin(adminID string) error {
+	if err := models.RevokeAdminRefreshTokens(a.db, adminID); err != nil {
+		return fmt.Errorf("[Auth.RevokeAdmin]%w", err)
+	}
+	tokens, err := models.GetActiveAdminAccessTokens(a.db, adminID)
+	if err != nil {
+		return fmt.Errorf("[Auth.RevokeAdmin]%w", err)
+	}
+	for i := range tokens {
+		if err := a.Revoke(&tokens[i]); err != nil {
+			return fmt.Errorf("[Auth.RevokeAdmin][Revoke]%w", err)
+		}
+	}
+	return nil
+}
+
+// RevokeSystem revokes every active access token of a system.
+func (a *Auth) RevokeSystem(systemID string) error {
+	tokens, err := models.GetActiveSystemAccessTokens(a.db, systemID)
+	if err != nil {
+		return fmt.Errorf("[Auth.RevokeSystem]%w", err)
+	}
+	for i := range tokens {
+		if err := a.Revoke(&tokens[i]); err != nil {
+			return fmt.Errorf("[Auth.RevokeSystem][Revoke]%w", err)
+		}
+	}
+	return nil
+}
+
+// RevokeAdminRefreshFamily revokes the refresh tokens of a family and the
+// access tokens issued with them.
+func (a *Auth) RevokeAdminRefreshFamily(familyID string) error {
+	if err := models.RevokeAdminRefreshTokenFamily(a.db, familyID); err != nil {
+		return fmt.Errorf("[Auth.RevokeAdminRefreshFamily]%w", err)
+	}
+	ids, err := models.GetAdminRefreshTokenFamilyAccessTokenIDs(a.db, familyID)
+	if err != nil {
+		return fmt.Errorf("[Auth.RevokeAdminRefreshFamily]%w", err)
+	}
+	for _, id := range ids {
+		token, found, err := models.GetAdminAccessTokenByID(a.db, id)
+		if err != nil {
+			return fmt.Errorf("[Auth.RevokeAdminRefreshFamily]%w", err)
+		} else if !found || token.RevokedAt.Valid {
+			continue
+		}
+		if err := a.Revoke(token); err != nil {
+			return fmt.Errorf("[Auth.RevokeAdminRefreshFamily][Revoke]%w", err)
+		}
+	}
+	return nil
+}
+
+// VerifyKeySet verifies tokens against the key named by their kid. Tokens
+// without kid, issued before the keys were named, are tried against every
+// key.
+func VerifyKeySet(keys jwk.Set) jwt.ParseOption {
+	return jwt.WithKeySet(keys, jws.WithRequireKid(false))
+}
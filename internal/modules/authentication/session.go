@@ -0,0 +1,146 @@
+package authentication
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/cache"
+	"gopkg.in/guregu/null.v4"
+)
+
+// AdminSession is a login of an admin. Refreshing replaces its access token,
+// the session is identified by the newest one.
+type AdminSession struct {
+	ID                 string                    `json:"id"`
+	RequestFingerprint models.RequestFingerprint `json:"request_fingerprint"`
+	IssuedAt           int64                     `json:"issued_at"`
+	LastSeenAt         null.Int                  `json:"last_seen_at"`
+	Current            bool                      `json:"current"`
+
+	familyID string
+	tokenIDs []string
+}
+
+func lastSeenKey(tokenID string) string {
+	return "auth:last_seen_" + tokenID
+}
+
+// TouchAdminToken records that an access token was just used. It only
+// writes to the cache, the entry goes away along with the token.
+func (a *Auth) TouchAdminToken(tokenID string, expiration time.Time) error {
+	opt := cache.Options{}
+	if !expiration.IsZero() {
+		opt.Expiration = time.Until(expiration) + time.Hour
+	}
+	if err := a.cache.PutValue(lastSeenKey(tokenID), time.Now().Unix(), &opt); err != nil {
+		return fmt.Errorf("[Auth.TouchAdminToken][PutValue]%w", err)
+	}
+	return nil
+}
+
+// GetAdminSessions lists the sessions of an admin, newest first. The session
+// the current token belongs to is flagged as current.
+func (a *Auth) GetAdminSessions(adminID string, currentTokenID string) ([]AdminSession, error) {
+	tokens, err := models.GetAdminSessionTokens(a.db, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("[Auth.GetAdminSessions]%w", err)
+	}
+
+	sessions := groupAdminSessions(tokens)
+	for i := range sessions {
+		session := &sessions[i]
+		for _, id := range session.tokenIDs {
+			if id == currentTokenID {
+				session.Current = true
+			}
+
+			var seen int64
+			_, err := a.cache.GetValue(lastSeenKey(id), &seen)
+			if errors.Is(err, cache.ErrKeyNotFound) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("[Auth.GetAdminSessions][GetValue]%w", err)
+			}
+			if seen > session.LastSeenAt.ValueOrZero() {
+				session.LastSeenAt = null.IntFrom(seen)
+			}
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeAdminSession ends the session the token belongs to, along with its
+// refresh tokens. False when the admin has no such session.
+func (a *Auth) RevokeAdminSession(adminID string, sessionID string) (bool, error) {
+	sessions, err := a.GetAdminSessions(adminID, "")
+	if err != nil {
+		return false, fmt.Errorf("[Auth.RevokeAdminSession]%w", err)
+	}
+	// An older token of the session still names it, in case the session
+	// was refreshed since it was listed
+	for _, session := range sessions {
+		if slices.Contains(session.tokenIDs, sessionID) {
+			return true, a.revokeAdminSession(session)
+		}
+	}
+	return false, nil
+}
+
+// RevokeOtherAdminSessions ends every session of an admin but the one the
+// current token belongs to.
+func (a *Auth) RevokeOtherAdminSessions(adminID string, currentTokenID string) error {
+	sessions, err := a.GetAdminSessions(adminID, currentTokenID)
+	if err != nil {
+		return fmt.Errorf("[Auth.RevokeOtherAdminSessions]%w", err)
+	}
+	for _, session := range sessions {
+		if session.Current {
+			continue
+		}
+		if err := a.revokeAdminSession(session); err != nil {
+			return fmt.Errorf("[Auth.RevokeOtherAdminSessions]%w", err)
+		}
+	}
+	return nil
+}
+
+func (a *Auth) revokeAdminSession(session AdminSession) error {
+	if session.familyID != "" {
+		return a.RevokeAdminRefreshFamily(session.familyID)
+	}
+	token, found, err := models.GetAdminAccessTokenByID(a.db, session.ID)
+	if err != nil {
+		return fmt.Errorf("[Auth.revokeAdminSession]%w", err)
+	} else if !found || token.RevokedAt.Valid {
+		return nil
+	}
+	return a.Revoke(token)
+}
+
+// groupAdminSessions folds the tokens of a refresh token family into one
+// session. The tokens come newest first, so does the newest token of a
+// session.
+func groupAdminSessions(tokens []models.AdminSessionToken) []AdminSession {
+	sessions := []AdminSession{}
+	families := map[string]int{}
+	for _, token := range tokens {
+		if token.FamilyID.Valid {
+			if i, ok := families[token.FamilyID.String]; ok {
+				sessions[i].tokenIDs = append(sessions[i].tokenIDs, token.ID)
+				continue
+			}
+			families[token.FamilyID.String] = len(sessions)
+		}
+		sessions = append(sessions, AdminSession{
+			ID:                 token.ID,
+			RequestFingerprint: token.RequestFingerprint,
+			IssuedAt:           token.CreatedAt,
+			familyID:           token.FamilyID.String,
+			tokenIDs:           []string{token.ID},
+		})
+	}
+	return sessions
+}
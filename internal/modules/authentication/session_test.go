@@ -0,0 +1,41 @@
+package authentication
+
+import (
+	"testing"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestGroupAdminSessions(t *testing.T) {
+	token := func(id string, familyID string, createdAt int64) models.AdminSessionToken {
+		var st models.AdminSessionToken
+		st.ID = id
+		st.CreatedAt = createdAt
+		st.RequestFingerprint.UserAgent = "agent " + id
+		if familyID != "" {
+			st.FamilyID = null.StringFrom(familyID)
+		}
+		return st
+	}
+
+	sessions := groupAdminSessions([]models.AdminSessionToken{
+		token("c", "f1", 30),
+		token("b", "f2", 20),
+		token("a", "f1", 10),
+		token("legacy", "", 5),
+	})
+
+	if len(sessions) != 3 {
+		t.Fatalf("want 3 sessions; got %d", len(sessions))
+	}
+	if s := sessions[0]; s.ID != "c" || s.IssuedAt != 30 || s.RequestFingerprint.UserAgent != "agent c" {
+		t.Errorf("want the newest token to name the session; got %+v", s)
+	}
+	if got := sessions[0].tokenIDs; len(got) != 2 || got[1] != "a" {
+		t.Errorf("want the older token of the family kept; got %v", got)
+	}
+	if s := sessions[2]; s.ID != "legacy" || s.familyID != "" {
+		t.Errorf("want a token without family on its own; got %+v", s)
+	}
+}
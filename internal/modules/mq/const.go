@@ -12,4 +12,5 @@ const (
 	MerchantUpdatedTopic                 = "merchant_updated"
 	StoreUpdatedTopic                    = "store_updated"
 	OrderUpdatedTopic                    = "order_updated"
+	PriceChangeRequestedTopic            = "price_change_requested"
 )
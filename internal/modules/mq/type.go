@@ -44,3 +44,9 @@ type StoreUpdatedMsg struct {
 type OrderUpdatedMsg struct {
 	OrderID string `json:"order_id"`
 }
+
+type PriceChangeRequestedMsg struct {
+	PriceChangeRequestID string   `json:"price_change_request_id"`
+	PhoneID              int      `json:"phone_id"`
+	ApproverIDs          []string `json:"approver_ids"`
+}
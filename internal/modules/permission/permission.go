@@ -0,0 +1,71 @@
+// Package permission resolves the permissions of admins through their role,
+// caching the permissions of every role.
+package permission
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/cache"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+)
+
+// CacheExpiration bounds how long a role change made without Invalidate,
+// directly in the database for example, takes to apply.
+const CacheExpiration = 10 * time.Minute
+
+func cacheKey(roleID string) string {
+	return "auth:role_permissions_" + roleID
+}
+
+// RolePermissions returns the permission identifiers of a role, reading them
+// from the cache when it holds them.
+func RolePermissions(c cache.Cache, db database.Queryer, roleID string) ([]string, error) {
+	var identifiers []string
+	_, err := c.GetValue(cacheKey(roleID), &identifiers)
+	if err == nil {
+		return identifiers, nil
+	} else if !errors.Is(err, cache.ErrKeyNotFound) {
+		return nil, fmt.Errorf("[RolePermissions][GetValue]%w", err)
+	}
+
+	identifiers, err = models.GetRolePermissionIdentifiers(db, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("[RolePermissions]%w", err)
+	}
+	if err := c.PutValue(cacheKey(roleID), identifiers, &cache.Options{Expiration: CacheExpiration}); err != nil {
+		return nil, fmt.Errorf("[RolePermissions][PutValue]%w", err)
+	}
+	return identifiers, nil
+}
+
+// Invalidate forgets the cached permissions of a role, it has to be called
+// whenever the permissions of the role change or the role is deleted.
+func Invalidate(c cache.Cache, roleID string) error {
+	if err := c.Delete(cacheKey(roleID)); err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+		return fmt.Errorf("[Invalidate][Delete]%w", err)
+	}
+	return nil
+}
+
+// AdminHas reports whether the role of the admin holds the permission.
+// Admins without a role hold none.
+func AdminHas(c cache.Cache, db database.Queryer, admin *models.Admin, identifier string) (bool, error) {
+	roleID := admin.RoleID.ValueOrZero()
+	if roleID == "" {
+		return false, nil
+	}
+
+	identifiers, err := RolePermissions(c, db, roleID)
+	if err != nil {
+		return false, fmt.Errorf("[AdminHas]%w", err)
+	}
+	for _, id := range identifiers {
+		if id == identifier {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,48 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/cache"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestAdminHas(t *testing.T) {
+	c := cache.NewInMemoryCache()
+	err := c.PutValue(cacheKey("roles:editor"), []string{models.PermissionPhoneCreate}, &cache.Options{Expiration: CacheExpiration})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only cached roles are resolved, a nil database fails any lookup
+	editor := &models.Admin{RoleID: null.StringFrom("roles:editor")}
+	tests := []struct {
+		admin      *models.Admin
+		permission string
+		want       bool
+	}{
+		{editor, models.PermissionPhoneCreate, true},
+		{editor, models.PermissionPhoneDelete, false},
+		{&models.Admin{}, models.PermissionPhoneCreate, false},
+	}
+	for _, tt := range tests {
+		got, err := AdminHas(c, nil, tt.admin, tt.permission)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: want %v; got %v", tt.permission, tt.want, got)
+		}
+	}
+
+	if err := Invalidate(c, "roles:editor"); err != nil {
+		t.Fatal(err)
+	}
+	if has, _ := c.Has(cacheKey("roles:editor")); has {
+		t.Error("want the role permissions forgotten")
+	}
+	if err := Invalidate(c, "roles:editor"); err != nil {
+		t.Errorf("want no error invalidating twice; got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package pricing
+
+import (
+	"errors"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+const (
+	// RuleRounding rounds a price up so it ends in the rule value, for example
+	// 9, 90 or 900.
+	RuleRounding = "rounding"
+	// RuleMinMargin raises a price to keep at least the rule value, in percent,
+	// over the cost price.
+	RuleMinMargin = "min_margin"
+	// RuleMaxStep requires a confirmation when a price moves by more than the
+	// rule value, in percent, from the previous price.
+	RuleMaxStep = "max_step"
+)
+
+var ErrConfirmationRequired = errors.New("pricing: price change requires confirmation")
+
+type Rule struct {
+	ID   int
+	Name string
+	Type string
+	// Percent is the value of min margin and max step rules.
+	Percent float64
+	// Ending is the value of rounding rules.
+	Ending  money.Amount
+	BrandID *int
+}
+
+type Input struct {
+	Price         money.Amount
+	PreviousPrice *money.Amount
+	CostPrice     *money.Amount
+	BrandID       int
+	Confirmed     bool
+}
+
+// Adjustment describes a rule that changed, or flagged, the price.
+type Adjustment struct {
+	RuleID   int          `json:"rule_id"`
+	RuleName string       `json:"rule_name"`
+	RuleType string       `json:"rule_type"`
+	Before   money.Amount `json:"before"`
+	After    money.Amount `json:"after"`
+}
+
+type Result struct {
+	RequestedPrice       money.Amount `json:"requested_price"`
+	Price                money.Amount `json:"price"`
+	Adjustments          []Adjustment `json:"adjustments"`
+	ConfirmationRequired bool         `json:"confirmation_required"`
+}
+
+// Err returns ErrConfirmationRequired when a max step rule was hit without
+// confirmation.
+func (r Result) Err() error {
+	if r.ConfirmationRequired {
+		return ErrConfirmationRequired
+	}
+	return nil
+}
+
+// Evaluate runs the rules matching the input brand. Margin floors are applied
+// first so rounding never brings a price back under its floor, step limits are
+// checked last against the final price.
+func Evaluate(rules []Rule, in Input) Result {
+	result := Result{
+		RequestedPrice: in.Price,
+		Price:          in.Price,
+		Adjustments:    []Adjustment{},
+	}
+
+	for _, ruleType := range []string{RuleMinMargin, RuleRounding, RuleMaxStep} {
+		for _, rule := range rules {
+			if rule.Type != ruleType || (rule.BrandID != nil && *rule.BrandID != in.BrandID) {
+				continue
+			}
+
+			before := result.Price
+			switch rule.Type {
+			case RuleMinMargin:
+				result.Price = applyMinMargin(result.Price, in.CostPrice, rule.Percent)
+			case RuleRounding:
+				result.Price = Round(result.Price, rule.Ending)
+			case RuleMaxStep:
+				if !exceedsStep(result.Price, in.PreviousPrice, rule.Percent) {
+					continue
+				}
+				if !in.Confirmed {
+					result.ConfirmationRequired = true
+				}
+			}
+
+			if before != result.Price || rule.Type == RuleMaxStep {
+				result.Adjustments = append(result.Adjustments, Adjustment{
+					RuleID:   rule.ID,
+					RuleName: rule.Name,
+					RuleType: rule.Type,
+					Before:   before,
+					After:    result.Price,
+				})
+			}
+		}
+	}
+	return result
+}
+
+// Round rounds a price up to the next value ending in ending, for instance
+// 12,347 becomes 12,349, 12,390 or 12,900 with an ending of 9, 90 or 900.
+func Round(price, ending money.Amount) money.Amount {
+	if ending <= 0 || price <= 0 {
+		return price
+	}
+	step := money.FromInt(1)
+	for step <= ending {
+		step *= 10
+	}
+	if price <= ending {
+		return ending
+	}
+	return (price-ending).RoundTo(step, money.Up) + ending
+}
+
+func applyMinMargin(price money.Amount, cost *money.Amount, margin float64) money.Amount {
+	if cost == nil || *cost <= 0 {
+		return price
+	}
+	return money.Max(price, cost.Percent(100+margin, money.Up))
+}
+
+// ExceedsPercent reports whether price moved from previous by more than
+// maxPercent percent.
+func ExceedsPercent(price money.Amount, previous money.Amount, maxPercent float64) bool {
+	if previous <= 0 {
+		return false
+	}
+	diff := price - previous
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff > previous.Percent(maxPercent, money.Down)
+}
+
+func exceedsStep(price money.Amount, previous *money.Amount, maxPercent float64) bool {
+	if previous == nil {
+		return false
+	}
+	return ExceedsPercent(price, *previous, maxPercent)
+}
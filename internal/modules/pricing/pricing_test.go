@@ -0,0 +1,70 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		price  money.Amount
+		ending money.Amount
+		want   money.Amount
+	}{
+		{money.FromInt(12347), money.FromInt(9), money.FromInt(12349)},
+		{money.FromInt(12347), money.FromInt(90), money.FromInt(12390)},
+		{money.FromInt(12347), money.FromInt(900), money.FromInt(12900)},
+		{money.FromInt(12390), money.FromInt(90), money.FromInt(12390)},
+		{money.FromInt(12391), money.FromInt(90), money.FromInt(12490)},
+		{money.FromCents(1239001), money.FromInt(90), money.FromInt(12490)},
+		{money.FromInt(50), money.FromInt(900), money.FromInt(900)},
+	}
+
+	for _, tt := range tests {
+		if got := Round(tt.price, tt.ending); got != tt.want {
+			t.Errorf("Round(%v, %v): want %v; got %v", tt.price, tt.ending, tt.want, got)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	cost := money.FromInt(10000)
+	previous := money.FromInt(10000)
+	brand := 2
+	rules := []Rule{
+		{ID: 1, Name: "round to 90", Type: RuleRounding, Ending: money.FromInt(90)},
+		{ID: 2, Name: "20% margin", Type: RuleMinMargin, Percent: 20},
+		{ID: 3, Name: "max 25% step", Type: RuleMaxStep, Percent: 25},
+		{ID: 4, Name: "other brand", Type: RuleRounding, Ending: money.FromInt(9000), BrandID: &brand},
+	}
+
+	t.Run("raises to the margin floor before rounding", func(t *testing.T) {
+		result := Evaluate(rules, Input{Price: money.FromInt(11000), CostPrice: &cost, BrandID: 1})
+		if result.Price != money.FromInt(12090) {
+			t.Errorf("want %v; got %v", money.FromInt(12090), result.Price)
+		}
+		if len(result.Adjustments) != 2 || result.Adjustments[0].RuleID != 2 || result.Adjustments[1].RuleID != 1 {
+			t.Errorf("want rules %v then %v; got %+v", 2, 1, result.Adjustments)
+		}
+	})
+
+	t.Run("requires confirmation for large steps", func(t *testing.T) {
+		result := Evaluate(rules, Input{Price: money.FromInt(14000), PreviousPrice: &previous, BrandID: 1})
+		if !result.ConfirmationRequired || result.Err() != ErrConfirmationRequired {
+			t.Errorf("want confirmation required; got %+v", result)
+		}
+
+		result = Evaluate(rules, Input{Price: money.FromInt(14000), PreviousPrice: &previous, BrandID: 1, Confirmed: true})
+		if result.ConfirmationRequired {
+			t.Errorf("want confirmed change accepted; got %+v", result)
+		}
+	})
+
+	t.Run("skips rules of other brands", func(t *testing.T) {
+		result := Evaluate(rules, Input{Price: money.FromInt(100), BrandID: 1})
+		if result.Price != money.FromInt(190) {
+			t.Errorf("want %v; got %v", money.FromInt(190), result.Price)
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/cache"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/random"
 )
 
 var ErrRateLimited = errors.New("request failed due to rate-limit")
@@ -38,9 +39,9 @@ func New(cache cache.Cache, namespace string, ids []string, expiration time.Dura
 }
 
 func (l *RateLimiter) Count() (int, error) {
-	attempts, err := l.cache.GetKeysWithPrefix(l.KeyPrefix)
+	attempts, err := l.cache.GetKeysWithPrefix(l.KeyPrefix + ":")
 	if err != nil {
-		return -1, nil
+		return -1, err
 	}
 	return len(attempts), nil
 }
@@ -57,17 +58,52 @@ func (l *RateLimiter) Guard(max int) error {
 }
 
 func (l *RateLimiter) RecordAttempt() error {
-	now := time.Now().Unix()
-	key := fmt.Sprintf("%s:%d", l.KeyPrefix, now)
+	_, err := l.record()
+	return err
+}
+
+// Reserve records an attempt before it is made and fails with
+// ErrRateLimited when it goes over max. Each attempt counts the ones
+// recorded before it, so no more than max of concurrent attempts get
+// through. The returned key takes the attempt back with Release.
+func (l *RateLimiter) Reserve(max int) (string, error) {
+	key, err := l.record()
+	if err != nil {
+		return "", err
+	}
+	count, err := l.Count()
+	if err != nil {
+		return key, err
+	}
+	if count > max {
+		return key, ErrRateLimited
+	}
+	return key, nil
+}
+
+// Release takes back an attempt recorded by Reserve, for attempts which
+// turned out not to count.
+func (l *RateLimiter) Release(key string) error {
+	return l.cache.Delete(key)
+}
+
+// record stores an attempt under a key of its own, attempts made within the
+// same second all count.
+func (l *RateLimiter) record() (string, error) {
+	now := time.Now()
+	key := fmt.Sprintf("%s:%d:%s", l.KeyPrefix, now.UnixNano(), random.GenerateString(8, random.LowercaseAlphabeticCharset+random.NumericCharset))
 	opt := &cache.Options{Expiration: l.Expiration}
 	if l.Expiration <= 0 {
 		opt = nil
 	}
-	return l.cache.PutValue(key, now, opt)
+	if err := l.cache.PutValue(key, now.Unix(), opt); err != nil {
+		return "", err
+	}
+	return key, nil
 }
 
 func (l *RateLimiter) Clear() error {
-	keys, err := l.cache.GetKeysWithPrefix(l.KeyPrefix)
+	keys, err := l.cache.GetKeysWithPrefix(l.KeyPrefix + ":")
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,33 @@
+package ratelimiter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/cache"
+)
+
+func TestReserve(t *testing.T) {
+	limiter := New(cache.NewInMemoryCache(), "test", []string{"client", "systems:1"}, time.Minute)
+
+	// All attempts land within the same second, each one still counts
+	var keys []string
+	for i := 0; i < 3; i++ {
+		key, err := limiter.Reserve(3)
+		if err != nil {
+			t.Fatalf("attempt %d: want allowed; got %v", i+1, err)
+		}
+		keys = append(keys, key)
+	}
+	if _, err := limiter.Reserve(3); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("want the fourth attempt limited; got %v", err)
+	}
+
+	if err := limiter.Release(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := limiter.Count(); err != nil || count != 3 {
+		t.Errorf("want 3 attempts left after a release; got %d, %v", count, err)
+	}
+}
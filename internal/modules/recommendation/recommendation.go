@@ -0,0 +1,355 @@
+package recommendation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/modules/cache"
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/database"
+)
+
+const cacheKeyPrefix = "recommendation:phone_"
+
+// cacheExpiration bounds how long a ranking can live without a phone write,
+// so rankings of phones that silently became published are eventually rebuilt.
+const cacheExpiration = 24 * time.Hour
+
+type Weights struct {
+	Brand float64
+	Tags  float64
+	Price float64
+	Specs float64
+}
+
+var DefaultWeights = Weights{Brand: 1, Tags: 2, Price: 3, Specs: 2}
+
+func (w Weights) total() float64 {
+	return w.Brand + w.Tags + w.Price + w.Specs
+}
+
+type Candidate struct {
+	PhoneID int
+	BrandID int
+	Score   float64
+	Pinned  bool
+}
+
+type Result struct {
+	Similar      []Candidate
+	AlsoConsider []Candidate
+}
+
+type Recommender struct {
+	cache    cache.Cache
+	Weights  Weights
+	Limit    int
+	PoolSize int
+	mu       sync.Mutex
+	queue    refreshQueue
+}
+
+func New(c cache.Cache, weights Weights, limit int, poolSize int) *Recommender {
+	if weights.total() <= 0 {
+		weights = DefaultWeights
+	}
+	if limit <= 0 {
+		limit = 6
+	}
+	if poolSize < limit*2 {
+		poolSize = limit * 2
+	}
+	return &Recommender{
+		cache:    c,
+		Weights:  weights,
+		Limit:    limit,
+		PoolSize: poolSize,
+	}
+}
+
+// refreshQueue runs one refresh at a time and folds every request made while
+// it runs into a single follow-up run of the latest request.
+type refreshQueue struct {
+	mu      sync.Mutex
+	running bool
+	next    func()
+}
+
+func (q *refreshQueue) schedule(run func()) {
+	q.mu.Lock()
+	if q.running {
+		q.next = run
+		q.mu.Unlock()
+		return
+	}
+	q.running = true
+	q.mu.Unlock()
+
+	go func() {
+		for run != nil {
+			run()
+
+			q.mu.Lock()
+			run, q.next = q.next, nil
+			q.running = run != nil
+			q.mu.Unlock()
+		}
+	}()
+}
+
+// Score returns how similar b is to a, between 0 and 1.
+func Score(a, b models.Phone, w Weights) float64 {
+	total := w.total()
+	if total <= 0 {
+		return 0
+	}
+
+	score := 0.0
+	if a.BrandID == b.BrandID {
+		score += w.Brand
+	}
+	score += w.Tags * tagSimilarity(a.Tags, b.Tags)
+	score += w.Price * priceProximity(a.Price.Float64(), b.Price.Float64())
+	score += w.Specs * specCloseness(a.Specifications, b.Specifications)
+
+	return score / total
+}
+
+func tagSimilarity(a, b []models.Tag) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	set := map[int]bool{}
+	for _, t := range a {
+		set[t.ID] = true
+	}
+	union := len(set)
+	shared := 0
+	seen := map[int]bool{}
+	for _, t := range b {
+		if seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+		if set[t.ID] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	return float64(shared) / float64(union)
+}
+
+func priceProximity(a, b float64) float64 {
+	highest := math.Max(a, b)
+	if highest <= 0 {
+		return 1
+	}
+	return math.Max(0, 1-math.Abs(a-b)/highest)
+}
+
+func specCloseness(a, b string) float64 {
+	specsA := models.ParseSpecifications(a)
+	specsB := models.ParseSpecifications(b)
+	if len(specsA) == 0 || len(specsB) == 0 {
+		return 0
+	}
+
+	byKey := map[string]models.Specification{}
+	for _, s := range specsA {
+		byKey[s.Key] = s
+	}
+	union := len(byKey)
+	matching := 0
+	for _, s := range specsB {
+		other, ok := byKey[s.Key]
+		if !ok {
+			union++
+			continue
+		}
+		if other.Matches(s) {
+			matching++
+		}
+	}
+	return float64(matching) / float64(union)
+}
+
+// Rank scores every phone against the target and keeps the best poolSize
+// candidates. The target itself is never part of the result.
+func Rank(target models.Phone, phones []models.Phone, w Weights, poolSize int) []Candidate {
+	candidates := make([]Candidate, 0, len(phones))
+	for _, p := range phones {
+		if p.ID == target.ID {
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			PhoneID: p.ID,
+			BrandID: p.BrandID,
+			Score:   Score(target, p, w),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score == candidates[j].Score {
+			return candidates[i].PhoneID < candidates[j].PhoneID
+		}
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	if poolSize > 0 && len(candidates) > poolSize {
+		candidates = candidates[:poolSize]
+	}
+	return candidates
+}
+
+// Apply turns a ranking into the two lists shown on the phone detail page.
+// Pinned phones lead the similar list in their configured order, excluded
+// phones are dropped from both lists, and "also consider" is filled with the
+// best remaining phones from other brands.
+func Apply(target models.Phone, candidates []Candidate, overrides []models.RecommendationOverride, limit int) Result {
+	excluded := map[int]bool{target.ID: true}
+	var pinned []int
+	for _, o := range overrides {
+		switch o.Action {
+		case models.RecommendationOverrideExclude:
+			excluded[o.RelatedPhoneID] = true
+		case models.RecommendationOverridePin:
+			pinned = append(pinned, o.RelatedPhoneID)
+		}
+	}
+
+	byID := map[int]Candidate{}
+	for _, c := range candidates {
+		byID[c.PhoneID] = c
+	}
+
+	result := Result{Similar: []Candidate{}, AlsoConsider: []Candidate{}}
+	used := map[int]bool{}
+	for _, id := range pinned {
+		if excluded[id] || used[id] || len(result.Similar) >= limit {
+			continue
+		}
+		c, ok := byID[id]
+		if !ok {
+			c = Candidate{PhoneID: id}
+		}
+		c.Pinned = true
+		result.Similar = append(result.Similar, c)
+		used[id] = true
+	}
+
+	for _, c := range candidates {
+		if len(result.Similar) >= limit {
+			break
+		}
+		if excluded[c.PhoneID] || used[c.PhoneID] {
+			continue
+		}
+		result.Similar = append(result.Similar, c)
+		used[c.PhoneID] = true
+	}
+
+	for _, c := range candidates {
+		if len(result.AlsoConsider) >= limit {
+			break
+		}
+		if excluded[c.PhoneID] || used[c.PhoneID] || c.BrandID == target.BrandID {
+			continue
+		}
+		result.AlsoConsider = append(result.AlsoConsider, c)
+		used[c.PhoneID] = true
+	}
+
+	return result
+}
+
+// Refresh recomputes and caches the ranking of every published phone. It is
+// meant to run after each phone write, so a single write is enough to keep
+// every ranking in sync.
+func (r *Recommender) Refresh(db database.Queryer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	phones, err := models.GetPublishedPhones(db)
+	if err != nil {
+		return fmt.Errorf("[Recommender.Refresh]%w", err)
+	}
+
+	fresh := map[string]bool{}
+	for _, p := range phones {
+		key := cacheKey(p.ID)
+		if err := r.store(key, Rank(p, phones, r.Weights, r.PoolSize)); err != nil {
+			return fmt.Errorf("[Recommender.Refresh]%w", err)
+		}
+		fresh[key] = true
+	}
+
+	keys, err := r.cache.GetKeysWithPrefix(cacheKeyPrefix)
+	if err != nil {
+		return fmt.Errorf("[Recommender.Refresh][GetKeysWithPrefix]%w", err)
+	}
+	for _, key := range keys {
+		if fresh[key] {
+			continue
+		}
+		if err := r.cache.Delete(key); err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+			return fmt.Errorf("[Recommender.Refresh][Delete]%w", err)
+		}
+	}
+	return nil
+}
+
+// RefreshInBackground runs Refresh in the background. Calls made while a
+// refresh runs are coalesced into a single follow-up refresh, so a burst of
+// writes rebuilds the rankings at most twice.
+func (r *Recommender) RefreshInBackground(db database.Queryer, onError func(error)) {
+	r.queue.schedule(func() {
+		if err := r.Refresh(db); err != nil {
+			onError(err)
+		}
+	})
+}
+
+// Candidates returns the cached ranking for a phone. Rankings missing from the
+// cache, such as the one of an unpublished phone, are computed once and
+// cached.
+func (r *Recommender) Candidates(db database.Queryer, target models.Phone) ([]Candidate, error) {
+	key := cacheKey(target.ID)
+
+	var candidates []Candidate
+	_, err := r.cache.GetValue(key, &candidates)
+	if err == nil {
+		return candidates, nil
+	} else if !errors.Is(err, cache.ErrKeyNotFound) {
+		return nil, fmt.Errorf("[Recommender.Candidates][GetValue]%w", err)
+	}
+
+	phones, err := models.GetPublishedPhones(db)
+	if err != nil {
+		return nil, fmt.Errorf("[Recommender.Candidates]%w", err)
+	}
+	candidates = Rank(target, phones, r.Weights, r.PoolSize)
+	if err := r.store(key, candidates); err != nil {
+		return nil, fmt.Errorf("[Recommender.Candidates]%w", err)
+	}
+	return candidates, nil
+}
+
+func (r *Recommender) store(key string, candidates []Candidate) error {
+	if candidates == nil {
+		candidates = []Candidate{}
+	}
+	err := r.cache.PutValue(key, candidates, &cache.Options{Expiration: cacheExpiration})
+	if err != nil {
+		return fmt.Errorf("[Recommender.store][PutValue]%w", err)
+	}
+	return nil
+}
+
+func cacheKey(phoneID int) string {
+	return fmt.Sprintf("%s%d", cacheKeyPrefix, phoneID)
+}
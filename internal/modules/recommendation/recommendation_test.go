@@ -0,0 +1,127 @@
+package recommendation
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+
+	"github.com/xinchuantw/hoki-tabloid-backend/utils/money"
+)
+
+func TestRank(t *testing.T) {
+	target := models.Phone{ID: 1, BrandID: 1, Price: money.FromInt(30000), Specifications: "RAM: 8GB", Tags: []models.Tag{{ID: 1}}}
+	phones := []models.Phone{
+		target,
+		{ID: 2, BrandID: 1, Price: money.FromInt(31000), Specifications: "RAM: 8GB", Tags: []models.Tag{{ID: 1}}},
+		{ID: 3, BrandID: 2, Price: money.FromInt(90000), Specifications: "RAM: 16GB", Tags: []models.Tag{{ID: 2}}},
+		{ID: 4, BrandID: 2, Price: money.FromInt(29000), Specifications: "RAM: 8GB", Tags: []models.Tag{{ID: 1}}},
+	}
+
+	candidates := Rank(target, phones, DefaultWeights, 10)
+
+	t.Run("excludes the target phone", func(t *testing.T) {
+		for _, c := range candidates {
+			if c.PhoneID == target.ID {
+				t.Errorf("want target excluded; got %v", candidates)
+			}
+		}
+	})
+
+	t.Run("ranks closer phones first", func(t *testing.T) {
+		want := []int{2, 4, 3}
+		for i, id := range want {
+			if candidates[i].PhoneID != id {
+				t.Errorf("want %v at %d; got %v", id, i, candidates[i].PhoneID)
+			}
+		}
+	})
+
+	t.Run("respects weight configuration", func(t *testing.T) {
+		priceOnly := Rank(target, phones, Weights{Price: 1}, 10)
+		if priceOnly[0].PhoneID != 2 && priceOnly[0].PhoneID != 4 {
+			t.Errorf("want cheapest difference first; got %v", priceOnly)
+		}
+		if priceOnly[len(priceOnly)-1].PhoneID != 3 {
+			t.Errorf("want %v last; got %v", 3, priceOnly[len(priceOnly)-1].PhoneID)
+		}
+	})
+}
+
+func TestApply(t *testing.T) {
+	target := models.Phone{ID: 1, BrandID: 1}
+	candidates := []Candidate{
+		{PhoneID: 2, BrandID: 1, Score: 0.9},
+		{PhoneID: 3, BrandID: 2, Score: 0.8},
+		{PhoneID: 4, BrandID: 2, Score: 0.7},
+		{PhoneID: 5, BrandID: 3, Score: 0.6},
+	}
+	overrides := []models.RecommendationOverride{
+		{PhoneID: 1, RelatedPhoneID: 9, Action: models.RecommendationOverridePin},
+		{PhoneID: 1, RelatedPhoneID: 3, Action: models.RecommendationOverrideExclude},
+	}
+
+	result := Apply(target, candidates, overrides, 2)
+
+	t.Run("puts pinned phones first", func(t *testing.T) {
+		if result.Similar[0].PhoneID != 9 || !result.Similar[0].Pinned {
+			t.Errorf("want pinned %v first; got %v", 9, result.Similar)
+		}
+		if result.Similar[1].PhoneID != 2 {
+			t.Errorf("want %v; got %v", 2, result.Similar[1].PhoneID)
+		}
+	})
+
+	t.Run("drops excluded phones and keeps other brands in also consider", func(t *testing.T) {
+		want := []int{4, 5}
+		if len(result.AlsoConsider) != len(want) {
+			t.Fatalf("want %v; got %v", want, result.AlsoConsider)
+		}
+		for i, id := range want {
+			if result.AlsoConsider[i].PhoneID != id {
+				t.Errorf("want %v; got %v", id, result.AlsoConsider[i].PhoneID)
+			}
+		}
+	})
+}
+
+func TestRefreshQueue(t *testing.T) {
+	var q refreshQueue
+	started := make(chan int)
+	release := make(chan struct{})
+	runs := 0
+	run := func(id int) func() {
+		return func() {
+			runs++
+			started <- id
+			<-release
+		}
+	}
+
+	q.schedule(run(1))
+	if id := <-started; id != 1 {
+		t.Fatalf("want run %v; got %v", 1, id)
+	}
+	for id := 2; id <= 5; id++ {
+		q.schedule(run(id))
+	}
+	release <- struct{}{}
+
+	if id := <-started; id != 5 {
+		t.Errorf("want the latest request %v to run; got %v", 5, id)
+	}
+	release <- struct{}{}
+
+	for {
+		q.mu.Lock()
+		running := q.running
+		q.mu.Unlock()
+		if !running {
+			break
+		}
+		runtime.Gosched()
+	}
+	if runs != 2 {
+		t.Errorf("want %v runs; got %v", 2, runs)
+	}
+}
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+type Job func() error
+
+type task struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// Scheduler runs jobs periodically inside the server process. Each job runs
+// in its own goroutine and never overlaps with itself.
+type Scheduler struct {
+	tasks   []task
+	onError func(name string, err error)
+	// ticker makes the ticks of a job and the function stopping them
+	ticker func(interval time.Duration) (<-chan time.Time, func())
+
+	mu   sync.Mutex
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(onError func(name string, err error)) *Scheduler {
+	if onError == nil {
+		onError = func(string, error) {}
+	}
+	return &Scheduler{onError: onError, ticker: newTicker}
+}
+
+func newTicker(interval time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// Every registers a job to run once per interval. Jobs registered after Start
+// are only picked up by the next Start.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = append(s.tasks, task{name: name, interval: interval, job: job})
+}
+
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.run(t, s.stop)
+	}
+}
+
+// Stop signals every job to stop and waits for the running ones to finish.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if s.stop == nil {
+		s.mu.Unlock()
+		return
+	}
+	close(s.stop)
+	s.stop = nil
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(t task, stop chan struct{}) {
+	defer s.wg.Done()
+
+	ticks, stopTicks := s.ticker(t.interval)
+	defer stopTicks()
+
+	for {
+		s.runJob(t)
+		select {
+		case <-stop:
+			return
+		case <-ticks:
+		}
+	}
+}
+
+func (s *Scheduler) runJob(t task) {
+	if err := t.job(); err != nil {
+		s.onError(t.name, err)
+	}
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	ticks := make(chan time.Time)
+	runs := make(chan struct{}, 1)
+
+	s := New(nil)
+	s.ticker = func(time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}
+	s.Every("counting", time.Hour, func() error {
+		runs <- struct{}{}
+		return nil
+	})
+
+	s.Start()
+
+	t.Run("runs jobs immediately and on every tick", func(t *testing.T) {
+		<-runs
+		for i := 0; i < 2; i++ {
+			ticks <- time.Now()
+			<-runs
+		}
+	})
+
+	s.Stop()
+
+	t.Run("does not run after stop", func(t *testing.T) {
+		select {
+		case ticks <- time.Now():
+			t.Error("want no job waiting for ticks")
+		default:
+		}
+		select {
+		case <-runs:
+			t.Error("want no run after stop")
+		default:
+		}
+	})
+}
+
+func TestSchedulerReportsJobErrors(t *testing.T) {
+	var reported []string
+	s := New(func(name string, err error) {
+		reported = append(reported, name+": "+err.Error())
+	})
+
+	s.runJob(task{name: "failing", job: func() error { return errors.New("failed") }})
+	s.runJob(task{name: "passing", job: func() error { return nil }})
+
+	if len(reported) != 1 || reported[0] != "failing: failed" {
+		t.Errorf("want %v; got %v", []string{"failing: failed"}, reported)
+	}
+}
@@ -12,4 +12,7 @@ type Context struct {
 	App         *app.Registry
 	Auth        AuthInformation
 	HTTPContext context.Context
+	// Language is the locale negotiated for the request, one of the supported
+	// languages of the localizer.
+	Language string
 }
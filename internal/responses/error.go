@@ -3,152 +3,129 @@ package responses
 import (
 	"encoding/json"
 	"net/http"
-	"net/url"
 
+	"github.com/go-chi/chi/v5/middleware"
 	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
-type ErrorData struct {
+// ProblemContentType is the media type of error responses, RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// Problem is the body of every error response. ErrorCode is stable and
+// Type is derived from it, TraceID is the request ID also found in the logs.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
 	ErrorCode string `json:"error_code"`
-	Message   string `json:"message"`
+	TraceID   string `json:"trace_id,omitempty"`
 }
 
-func commonErrorHeader(w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+// NewProblem describes an error of the request, detail being localized from
+// "error.<code>" with fallback as the English message.
+func NewProblem(r *http.Request, t Translate, status int, code string, fallback string) Problem {
+	p := Problem{
+		Type:      "/problems/" + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    errorMessage(t, code, fallback),
+		ErrorCode: code,
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+		p.TraceID = middleware.GetReqID(r.Context())
+	}
+	return p
+}
+
+// WriteProblem writes p, with the members of extension, if any, next to the
+// problem members.
+func WriteProblem(w http.ResponseWriter, p Problem, extension any) {
+	w.Header().Set("Content-Type", ProblemContentType+"; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+
+	_ = json.NewEncoder(w).Encode(problemBody(p, extension))
 }
 
-func InternalServerError(w http.ResponseWriter, d any) {
-	commonErrorHeader(w)
-	w.WriteHeader(http.StatusInternalServerError)
-	_ = json.NewEncoder(w).Encode(struct {
-		ErrorData
-		Details any `json:"details,omitempty"`
-	}{
-		ErrorData: ErrorData{
-			ErrorCode: "internal_server_error",
-			Message:   "Server current cannot process this request",
-		},
-		Details: d,
-	})
+func problemBody(p Problem, extension any) any {
+	if extension == nil {
+		return p
+	}
+
+	body := map[string]any{}
+	if buf, err := json.Marshal(extension); err == nil {
+		_ = json.Unmarshal(buf, &body)
+	}
+	// The problem members take precedence over extension members
+	buf, _ := json.Marshal(p)
+	_ = json.Unmarshal(buf, &body)
+	return body
 }
 
-func ServiceUnavailable(w http.ResponseWriter) {
-	commonErrorHeader(w)
-	w.WriteHeader(http.StatusServiceUnavailable)
-	_ = json.NewEncoder(w).Encode(struct {
-		ErrorData
-	}{
-		ErrorData: ErrorData{
-			ErrorCode: "service_unavailable",
-			Message: "The service you want to access is currently unavailable. " +
-				"Please try again later.",
-		},
-	})
+func InternalServerError(w http.ResponseWriter, r *http.Request, t Translate, d any) {
+	p := NewProblem(r, t, http.StatusInternalServerError, "internal_server_error", "Server current cannot process this request")
+	if d == nil {
+		WriteProblem(w, p, nil)
+		return
+	}
+	WriteProblem(w, p, struct {
+		Details any `json:"details"`
+	}{d})
 }
 
-func Unauthenticated(w http.ResponseWriter) {
-	commonErrorHeader(w)
-	w.WriteHeader(http.StatusUnauthorized)
-	_ = json.NewEncoder(w).Encode(struct {
-		ErrorData
-	}{
-		ErrorData: ErrorData{
-			ErrorCode: "unauthenticated",
-			Message: "Cannot authenticate with provided credentials. " +
-				"Please check to make sure the credentials used are correct.",
-		},
-	})
+func ServiceUnavailable(w http.ResponseWriter, r *http.Request, t Translate) {
+	WriteProblem(w, NewProblem(r, t, http.StatusServiceUnavailable, "service_unavailable",
+		"The service you want to access is currently unavailable. "+
+			"Please try again later."), nil)
 }
 
-func Forbidden(w http.ResponseWriter) {
-	commonErrorHeader(w)
-	w.WriteHeader(http.StatusForbidden)
-	_ = json.NewEncoder(w).Encode(struct {
-		ErrorData
-	}{
-		ErrorData: ErrorData{
-			ErrorCode: "forbidden",
-			Message:   "you don't have authority to access this resource or perform this action",
-		},
-	})
+func Unauthenticated(w http.ResponseWriter, r *http.Request, t Translate) {
+	WriteProblem(w, NewProblem(r, t, http.StatusUnauthorized, "unauthenticated",
+		"Cannot authenticate with provided credentials. "+
+			"Please check to make sure the credentials used are correct."), nil)
 }
 
-func NotFound(w http.ResponseWriter, u *url.URL) {
-	commonErrorHeader(w)
-	w.WriteHeader(http.StatusNotFound)
+func Forbidden(w http.ResponseWriter, r *http.Request, t Translate) {
+	WriteProblem(w, NewProblem(r, t, http.StatusForbidden, "forbidden",
+		"you don't have authority to access this resource or perform this action"), nil)
+}
 
-	path := ""
-	if u != nil {
-		path = u.String()
-	}
-	_ = json.NewEncoder(w).Encode(struct {
-		ErrorData
-		Path string `json:"path,omitempty"`
-	}{
-		ErrorData: ErrorData{
-			ErrorCode: "not_found",
-			Message:   "Requested resource cannot be found",
-		},
-		Path: path,
-	})
+func NotFound(w http.ResponseWriter, r *http.Request, t Translate) {
+	WriteProblem(w, NewProblem(r, t, http.StatusNotFound, "not_found",
+		"Requested resource cannot be found"), nil)
 }
 
-func MalformedRequest(w http.ResponseWriter) {
-	commonErrorHeader(w)
-	w.WriteHeader(http.StatusUnprocessableEntity)
-	_ = json.NewEncoder(w).Encode(struct {
-		ErrorData
-	}{
-		ErrorData: ErrorData{
-			ErrorCode: "malformed_request",
-			Message: "Cannot decode malformed request. " +
-				"Please make sure the request is correctly formatted with all required fields.",
-		},
-	})
+func MalformedRequest(w http.ResponseWriter, r *http.Request, t Translate) {
+	WriteProblem(w, NewProblem(r, t, http.StatusUnprocessableEntity, "malformed_request",
+		"Cannot decode malformed request. "+
+			"Please make sure the request is correctly formatted with all required fields."), nil)
 }
 
-func ValidationError(w http.ResponseWriter, err validation.Errors) {
-	commonErrorHeader(w)
-	w.WriteHeader(http.StatusUnprocessableEntity)
-	_ = json.NewEncoder(w).Encode(struct {
-		ErrorData
+func ValidationError(w http.ResponseWriter, r *http.Request, t Translate, err validation.Errors) {
+	p := NewProblem(r, t, http.StatusUnprocessableEntity, "validation_error",
+		"Some fields in the request failed validation.")
+	WriteProblem(w, p, struct {
 		Fields validation.Errors `json:"fields"`
-	}{
-		ErrorData: ErrorData{
-			ErrorCode: "validation_error",
-			Message:   "Some fields in the request failed validation.",
-		},
-		Fields: err,
-	})
+	}{LocalizeValidationErrors(t, err)})
 }
 
-func UnprocessableEntity(w http.ResponseWriter, err httperr.ErrUnprocessableEntity) {
-	commonErrorHeader(w)
-	w.WriteHeader(http.StatusUnprocessableEntity)
-	_ = json.NewEncoder(w).Encode(struct {
-		ErrorData
-		Data any `json:"data,omitempty"`
-	}{
-		ErrorData: ErrorData{
-			ErrorCode: err.ErrorCode,
-			Message:   err.Message,
-		},
-		Data: err.Data,
-	})
+func UnprocessableEntity(w http.ResponseWriter, r *http.Request, t Translate, err httperr.ErrUnprocessableEntity) {
+	p := NewProblem(r, t, http.StatusUnprocessableEntity, err.ErrorCode, err.Message)
+	if err.Data == nil {
+		WriteProblem(w, p, nil)
+		return
+	}
+	WriteProblem(w, p, struct {
+		Data any `json:"data"`
+	}{err.Data})
 }
 
-func TooManyRequests(w http.ResponseWriter) {
-	commonErrorHeader(w)
-	w.WriteHeader(http.StatusTooManyRequests)
-	_ = json.NewEncoder(w).Encode(struct {
-		ErrorData
-	}{
-		ErrorData: ErrorData{
-			ErrorCode: "too_many_request",
-			Message:   "Cannot request resource due to limit rate",
-		},
-	})
+func TooManyRequests(w http.ResponseWriter, r *http.Request, t Translate) {
+	WriteProblem(w, NewProblem(r, t, http.StatusTooManyRequests, "too_many_request",
+		"Cannot request resource due to limit rate"), nil)
 }
@@ -0,0 +1,86 @@
+package responses
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	httperr "github.com/xinchuantw/hoki-tabloid-backend/internal/errors"
+)
+
+func TestProblemResponses(t *testing.T) {
+	var r *http.Request
+	middleware.RequestID(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		r = req
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/phones/12?currency=XXX", nil))
+	translate := func(messageID string, _ map[string]any) string {
+		if messageID == "error.not_found" {
+			return "找不到請求的資源"
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name      string
+		write     func(w http.ResponseWriter)
+		status    int
+		code      string
+		detail    string
+		extension string
+	}{
+		{"localized", func(w http.ResponseWriter) { NotFound(w, r, translate) }, 404, "not_found", "找不到請求的資源", ""},
+		{"fallback", func(w http.ResponseWriter) { Forbidden(w, r, translate) }, 403, "forbidden", "you don't have authority to access this resource or perform this action", ""},
+		{"internal", func(w http.ResponseWriter) { InternalServerError(w, r, nil, nil) }, 500, "internal_server_error", "Server current cannot process this request", ""},
+		{"debug", func(w http.ResponseWriter) { InternalServerError(w, r, nil, "[GetPhones][Select]boom") }, 500, "internal_server_error", "Server current cannot process this request", "details"},
+		{"validation", func(w http.ResponseWriter) {
+			ValidationError(w, r, nil, validation.Errors{"currency": validation.ErrRequired})
+		}, 422, "validation_error", "Some fields in the request failed validation.", "fields"},
+		{"unprocessable", func(w http.ResponseWriter) {
+			UnprocessableEntity(w, r, nil, httperr.NewErrUnprocessableEntity("schedule_conflict", "the schedule overlaps another price change", 1))
+		}, 422, "schedule_conflict", "the schedule overlaps another price change", "data"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.write(w)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: want status %d; got %d", tt.name, tt.status, w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/problem+json; charset=utf-8" {
+			t.Errorf("%s: want a problem+json response; got %s", tt.name, got)
+		}
+
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		want := map[string]any{
+			"type":       "/problems/" + tt.code,
+			"title":      http.StatusText(tt.status),
+			"status":     float64(tt.status),
+			"detail":     tt.detail,
+			"instance":   "/phones/12",
+			"error_code": tt.code,
+			"trace_id":   middleware.GetReqID(r.Context()),
+		}
+		for key, value := range want {
+			if body[key] != value {
+				t.Errorf("%s: want %s %v; got %v", tt.name, key, value, body[key])
+			}
+		}
+		members := len(want)
+		if tt.extension != "" {
+			members++
+			if body[tt.extension] == nil {
+				t.Errorf("%s: want the %s extension member", tt.name, tt.extension)
+			}
+		}
+		if len(body) != members {
+			t.Errorf("%s: unexpected members in %v", tt.name, body)
+		}
+	}
+}
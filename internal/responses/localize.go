@@ -0,0 +1,50 @@
+package responses
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Translate returns the message of messageID in the language of the request,
+// or an empty string when no locale file defines it. A nil Translate leaves
+// every message in English.
+type Translate func(messageID string, templateData map[string]any) string
+
+// errorMessage is the message of an error code, "error.<code>" in the locale
+// files, or fallback when it is not translated.
+func errorMessage(t Translate, code string, fallback string) string {
+	if t != nil {
+		if message := t("error."+code, nil); message != "" {
+			return message
+		}
+	}
+	return fallback
+}
+
+// LocalizeValidationErrors translates every validation error by its code,
+// "validation.<code>" in the locale files, with the error params as template
+// data. Errors without a code or a translation keep their message.
+func LocalizeValidationErrors(t Translate, errs validation.Errors) validation.Errors {
+	localized := make(validation.Errors, len(errs))
+	for field, err := range errs {
+		localized[field] = localizeValidationError(t, err)
+	}
+	return localized
+}
+
+func localizeValidationError(t Translate, err error) error {
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		return LocalizeValidationErrors(t, errs)
+	}
+
+	var e validation.Error
+	if t == nil || !errors.As(err, &e) || e.Code() == "" {
+		return err
+	}
+	if message := t("validation."+e.Code(), e.Params()); message != "" {
+		return e.SetMessage(message)
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package responses
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+func TestLocalizeValidationErrors(t *testing.T) {
+	translate := func(messageID string, data map[string]any) string {
+		switch messageID {
+		case "validation.validation_required":
+			return "不可為空"
+		case "validation.validation_length_too_long":
+			return fmt.Sprintf("長度不可超過 %v", data["max"])
+		}
+		return ""
+	}
+
+	errs := validation.Errors{
+		"name":   validation.ErrRequired,
+		"slug":   validation.ErrLengthTooLong.SetParams(map[string]any{"max": 10}),
+		"price":  validation.ErrInInvalid,
+		"plain":  errors.New("plain error"),
+		"nested": validation.Errors{"0": validation.ErrRequired},
+	}
+	localized := LocalizeValidationErrors(translate, errs)
+
+	want := map[string]string{
+		"name":   "不可為空",
+		"slug":   "長度不可超過 10",
+		"price":  "must be a valid value",
+		"plain":  "plain error",
+		"nested": "0: 不可為空.",
+	}
+	for field, message := range want {
+		if got := localized[field].Error(); got != message {
+			t.Errorf("%s: want %q; got %q", field, message, got)
+		}
+	}
+	if errs["name"].Error() != "cannot be blank" {
+		t.Errorf("the original errors should be left untouched")
+	}
+}
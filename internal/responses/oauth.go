@@ -0,0 +1,31 @@
+package responses
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// OAuthToken is the successful response of the token endpoint, RFC 6749
+// section 5.1.
+type OAuthToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// OAuthError is the error response of the token endpoint, RFC 6749 section
+// 5.2. OAuth clients expect this shape instead of a problem.
+type OAuthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// OAuth writes a token endpoint response, never to be cached.
+func OAuth(w http.ResponseWriter, status int, body any) error {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(body)
+}
@@ -4,4 +4,6 @@ type AuthToken struct {
 	AccessToken string `json:"access_token"`
 	TokenType   string `json:"token_type"`
 	ExpiresIn   int    `json:"expires_in"`
+	// RefreshToken trades for a new pair once, only issued to admins
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
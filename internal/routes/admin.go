@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/admin"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+func RegisterAdminRoutes(root chi.Router, app *app.Registry) {
+	adminController := admin.NewAdminController(app)
+
+	root.Route("/admins", func(r chi.Router) {
+		r.Use(middlewares.AdminAuthMiddleware(app))
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(app, models.PermissionAdminIndex))
+			r.Get("/", adminController.GetAdmins)
+			r.Get("/{AdminID}", adminController.GetAdmin)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(app, models.PermissionAdminManage))
+			r.Put("/{AdminID}/role", adminController.ChangeRole)
+			r.Post("/{AdminID}/deactivate", adminController.Deactivate)
+			r.Post("/{AdminID}/reactivate", adminController.Reactivate)
+			r.Post("/{AdminID}/logout", adminController.Logout)
+			r.Get("/{AdminID}/sessions", adminController.GetSessions)
+			r.Delete("/{AdminID}/sessions", adminController.RevokeOtherSessions)
+			r.Delete("/{AdminID}/sessions/{SessionID}", adminController.RevokeSession)
+		})
+	})
+}
@@ -10,19 +10,33 @@ import (
 func RegisterAuthRoutes(root chi.Router, app *app.Registry) {
 	root.Route("/auth", func(r chi.Router) {
 		r.Mount("/admin", AdminAuthRoutes(app))
+		r.Mount("/system", SystemAuthRoutes(app))
 	})
 }
 
+func SystemAuthRoutes(app *app.Registry) chi.Router {
+	controller := auth.NewAuthSystemController(app)
+	r := chi.NewRouter()
+
+	r.Post("/token", controller.Token)
+
+	return r
+}
+
 func AdminAuthRoutes(app *app.Registry) chi.Router {
 	controller := auth.NewAuthAdminController(app)
 	r := chi.NewRouter()
 
 	r.Post("/", controller.LoginByXinchuanAuth)
+	r.Post("/refresh", controller.Refresh)
 
 	r.Group(func(r chi.Router) {
 		r.Use(middlewares.AdminAuthMiddleware(app))
 		r.Get("/", controller.Me)
 		r.Delete("/", controller.Logout)
+		r.Get("/sessions", controller.Sessions)
+		r.Delete("/sessions", controller.RevokeOtherSessions)
+		r.Delete("/sessions/{SessionID}", controller.RevokeSession)
 	})
 
 	return r
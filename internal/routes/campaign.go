@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/campaign"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+func RegisterCampaignRoutes(root chi.Router, app *app.Registry) {
+	campaignController := campaign.NewCampaignController(app)
+
+	root.Route("/campaigns", func(r chi.Router) {
+		r.Use(middlewares.AdminAuthMiddleware(app))
+		r.Get("/", campaignController.GetCampaigns)
+		r.Get("/{CampaignID}", campaignController.GetCampaign)
+		can(r, app, models.PermissionCampaignManage).Post("/", campaignController.CreateCampaign)
+		can(r, app, models.PermissionCampaignManage).Put("/{CampaignID}", campaignController.UpdateCampaign)
+		can(r, app, models.PermissionCampaignManage).Delete("/{CampaignID}", campaignController.DeleteCampaign)
+	})
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/exchangerate"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+func RegisterExchangeRateRoutes(root chi.Router, app *app.Registry) {
+	exchangeRateController := exchangerate.NewExchangeRateController(app)
+
+	root.Route("/exchange-rates", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.AuthMiddleware(app))
+			scoped(r, models.SystemScopePricesRead).Get("/", exchangeRateController.GetExchangeRates)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.AdminAuthMiddleware(app))
+			can(r, app, models.PermissionExchangeRateManage).Put("/{Currency}", exchangeRateController.SaveExchangeRate)
+			can(r, app, models.PermissionExchangeRateManage).Delete("/{Currency}", exchangeRateController.DeleteExchangeRate)
+		})
+	})
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/public"
+)
+
+func RegisterKeyRoutes(root chi.Router, app *app.Registry) {
+	keysController := public.NewKeysController(app)
+
+	root.Get("/.well-known/jwks.json", keysController.Keys)
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/locale"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+func RegisterLocaleRoutes(root chi.Router, app *app.Registry) {
+	localeController := locale.NewLocaleController(app)
+
+	root.Route("/locales", func(r chi.Router) {
+		r.Use(middlewares.AdminAuthMiddleware(app))
+		r.Get("/", localeController.GetLocales)
+		r.Get("/{Locale}", localeController.GetLocaleMessages)
+		r.Get("/{Locale}/missing", localeController.GetMissingMessages)
+		can(r, app, models.PermissionLocaleManage).Put("/{Locale}", localeController.UpdateLocaleMessages)
+	})
+}
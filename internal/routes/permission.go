@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+)
+
+// can restricts the routes registered on the returned router to admins whose
+// role holds the permission, and systems holding a scope standing for it.
+func can(r chi.Router, app *app.Registry, permission string) chi.Router {
+	return r.With(middlewares.RequirePermission(app, permission))
+}
+
+// scoped restricts the routes registered on the returned router to admins
+// and systems holding the scope.
+func scoped(r chi.Router, scope string) chi.Router {
+	return r.With(middlewares.RequireScope(scope))
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
 	controller "github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/phone"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
 )
 
 func RegisterPhoneRoutes(root chi.Router, app *app.Registry) {
@@ -13,11 +14,31 @@ func RegisterPhoneRoutes(root chi.Router, app *app.Registry) {
 	root.Route("/phones", func(r chi.Router) {
 		r.Group(func(r chi.Router) {
 			r.Use(middlewares.AuthMiddleware(app))
-			r.Post("/", phoneController.CreatePhone)
-			r.Get("/{PhoneID}", phoneController.GetPhone)
-			r.Patch("/{PhoneID}", phoneController.UpdatePhone)
-			r.Delete("/{PhoneID}", phoneController.DeletePhone)
-			r.Get("/", phoneController.GetPhones)
+			can(r, app, models.PermissionPhoneCreate).Post("/", phoneController.CreatePhone)
+			can(r, app, models.PermissionPhoneUpdate).Patch("/{PhoneID}", phoneController.UpdatePhone)
+			can(r, app, models.PermissionPhoneDelete).Delete("/{PhoneID}", phoneController.DeletePhone)
+			scoped(r, models.SystemScopeCatalogRead).Get("/compare", phoneController.ComparePhones)
+			scoped(r, models.SystemScopeCatalogRead).Get("/{PhoneID}", phoneController.GetPhone)
+			scoped(r, models.SystemScopeCatalogRead).Get("/", phoneController.GetPhones)
+			scoped(r, models.SystemScopeCatalogRead).Get("/{PhoneID}/recommendations", phoneController.GetRecommendations)
+			scoped(r, models.SystemScopePricesRead).Get("/{PhoneID}/price-history", phoneController.GetPriceHistory)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.AdminAuthMiddleware(app))
+			r.Get("/{PhoneID}/recommendations/overrides", phoneController.GetRecommendationOverrides)
+			r.Get("/{PhoneID}/price-schedules", phoneController.GetScheduledPriceChanges)
+
+			r.Group(func(r chi.Router) {
+				r.Use(middlewares.RequirePermission(app, models.PermissionPhoneUpdate))
+				r.Post("/bulk", phoneController.BulkEditPhones)
+				r.Put("/{PhoneID}/recommendations/overrides", phoneController.UpdateRecommendationOverrides)
+				r.Put("/{PhoneID}/accessories", phoneController.UpdateCompatibleAccessories)
+				r.Post("/{PhoneID}/accessories/copy", phoneController.CopyCompatibility)
+				r.Put("/{PhoneID}/compatible-devices", phoneController.UpdateCompatibleDevices)
+				r.Post("/{PhoneID}/price-schedules", phoneController.CreateScheduledPriceChange)
+				r.Delete("/{PhoneID}/price-schedules/{ScheduleID}", phoneController.CancelScheduledPriceChange)
+			})
 		})
 	})
 }
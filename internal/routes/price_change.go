@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/pricechange"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+)
+
+func RegisterPriceChangeRoutes(root chi.Router, app *app.Registry) {
+	priceChangeController := pricechange.NewPriceChangeController(app)
+
+	root.Route("/price-change-requests", func(r chi.Router) {
+		r.Use(middlewares.AdminAuthMiddleware(app))
+		r.Get("/", priceChangeController.GetPriceChangeRequests)
+		r.Get("/{RequestID}", priceChangeController.GetPriceChangeRequest)
+		r.Post("/{RequestID}/approve", priceChangeController.Approve)
+		r.Post("/{RequestID}/reject", priceChangeController.Reject)
+	})
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/pricerule"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+func RegisterPriceRuleRoutes(root chi.Router, app *app.Registry) {
+	priceRuleController := pricerule.NewPriceRuleController(app)
+
+	root.Route("/price-rules", func(r chi.Router) {
+		r.Use(middlewares.AdminAuthMiddleware(app))
+		r.Get("/", priceRuleController.GetPriceRules)
+		can(r, app, models.PermissionPriceRuleManage).Post("/", priceRuleController.CreatePriceRule)
+		can(r, app, models.PermissionPriceRuleManage).Post("/preview", priceRuleController.Preview)
+		can(r, app, models.PermissionPriceRuleManage).Put("/{RuleID}", priceRuleController.UpdatePriceRule)
+		can(r, app, models.PermissionPriceRuleManage).Delete("/{RuleID}", priceRuleController.DeletePriceRule)
+	})
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/role"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+func RegisterRoleRoutes(root chi.Router, app *app.Registry) {
+	roleController := role.NewRoleController(app)
+
+	root.Route("/permissions", func(r chi.Router) {
+		r.Use(middlewares.AdminAuthMiddleware(app))
+		can(r, app, models.PermissionAdminIndex).Get("/", roleController.GetPermissions)
+	})
+
+	root.Route("/roles", func(r chi.Router) {
+		r.Use(middlewares.AdminAuthMiddleware(app))
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(app, models.PermissionAdminIndex))
+			r.Get("/", roleController.GetRoles)
+			r.Get("/{RoleID}", roleController.GetRole)
+			r.Get("/{RoleID}/admins", roleController.GetRoleAdmins)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequirePermission(app, models.PermissionRoleManage))
+			r.Post("/", roleController.CreateRole)
+			r.Patch("/{RoleID}", roleController.UpdateRole)
+			r.Put("/{RoleID}/permissions", roleController.UpdateRolePermissions)
+			r.Delete("/{RoleID}", roleController.DeleteRole)
+		})
+	})
+}
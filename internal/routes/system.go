@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/system"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+func RegisterSystemRoutes(root chi.Router, app *app.Registry) {
+	systemController := system.NewSystemController(app)
+
+	root.Route("/systems", func(r chi.Router) {
+		r.Use(middlewares.AdminAuthMiddleware(app))
+		r.Use(middlewares.RequirePermission(app, models.PermissionSystemManage))
+		r.Get("/", systemController.GetSystems)
+		r.Post("/", systemController.CreateSystem)
+		r.Get("/{SystemID}", systemController.GetSystem)
+		r.Put("/{SystemID}/scopes", systemController.UpdateScopes)
+		r.Post("/{SystemID}/rotate", systemController.RotateSecret)
+		r.Post("/{SystemID}/revoke", systemController.RevokeSystem)
+	})
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/app"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/controllers/translation"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/middlewares"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
+)
+
+func RegisterTranslationRoutes(root chi.Router, app *app.Registry) {
+	translationController := translation.NewTranslationController(app)
+
+	root.Route("/translations/{EntityType}/{EntityID}", func(r chi.Router) {
+		r.Use(middlewares.AdminAuthMiddleware(app))
+		r.Get("/", translationController.GetTranslations)
+		can(r, app, models.PermissionTranslationManage).Put("/{Locale}", translationController.UpdateTranslations)
+		can(r, app, models.PermissionTranslationManage).Delete("/{Locale}", translationController.DeleteTranslations)
+	})
+}
@@ -11,18 +11,43 @@ import (
 
 	"github.com/golang-migrate/migrate/v4/database/mysql"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/jobs"
+	"github.com/xinchuantw/hoki-tabloid-backend/internal/models"
 	"github.com/xinchuantw/hoki-tabloid-backend/internal/routes"
 )
 
 func (s *Server) BeforeStart() {
 	migrateDatabase(s)
+	if err := models.SyncPermissions(s.App.DB); err != nil {
+		panic(err.Error())
+	}
 	if err := s.App.Auth.LoadRevocationList(); err != nil {
 		panic(err.Error())
 	}
+	if err := jobs.ReloadLocalizer(s.App); err != nil {
+		panic(err.Error())
+	}
+	registerJobs(s)
 }
 
 func (s *Server) AfterStart() {
+	go func() {
+		if err := s.App.Recommender.Refresh(s.App.DB); err != nil {
+			s.App.Log.Errorf("[Server.AfterStart] refresh recommendations: %v", err)
+		}
+	}()
+	s.App.Scheduler.Start()
+}
 
+func registerJobs(s *Server) {
+	tick := time.Duration(s.App.Config.Scheduler.TickSeconds) * time.Second
+	if tick <= 0 {
+		tick = 30 * time.Second
+	}
+	s.App.Scheduler.Every("scheduled_price_changes", tick, jobs.ApplyScheduledPriceChanges(s.App))
+	s.App.Scheduler.Every("campaign_prices", tick, jobs.SyncCampaignPrices(s.App))
+	s.App.Scheduler.Every("locale_messages", tick, jobs.ReloadLocaleMessages(s.App))
+	s.App.Scheduler.Every("revocation_list", tick, jobs.ReloadRevocationList(s.App))
 }
 
 func (s *Server) RegisterRoutes() []RouteRegister {
@@ -30,6 +55,16 @@ func (s *Server) RegisterRoutes() []RouteRegister {
 		routes.RegisterAccountRoutes,
 		routes.RegisterAuthRoutes,
 		routes.RegisterPhoneRoutes,
+		routes.RegisterCampaignRoutes,
+		routes.RegisterPriceRuleRoutes,
+		routes.RegisterPriceChangeRoutes,
+		routes.RegisterExchangeRateRoutes,
+		routes.RegisterTranslationRoutes,
+		routes.RegisterLocaleRoutes,
+		routes.RegisterRoleRoutes,
+		routes.RegisterAdminRoutes,
+		routes.RegisterSystemRoutes,
+		routes.RegisterKeyRoutes,
 	}
 }
 
@@ -36,15 +36,17 @@ func NewWithConfig(cfg *config.Config) *Server {
 
 	router := chi.NewRouter()
 	router.Use(middlewares.MetricsMiddleware(cfg.Public.PrometheusAPIJobName))
+	router.Use(middleware.RequestID)
 	router.NotFound(middlewares.NotFound(registry))
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{"HEAD", "GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-Geolocation"},
+		AllowedHeaders: []string{"Accept", "Accept-Language", "Authorization", "Content-Type", "X-Geolocation", "X-Request-Id"},
 	}))
 	router.Use(middleware.Logger)
 	router.Use(middleware.StripSlashes)
 	router.Use(middleware.CleanPath)
+	router.Use(middlewares.Locale(registry))
 	router.Use(middlewares.Recover(registry))
 
 	server := Server{
@@ -96,4 +98,6 @@ func (s *Server) Shutdown() {
 			log.Fatalf("Server shutdown failed: %+v", err)
 		}
 	}
+
+	s.App.Scheduler.Stop()
 }
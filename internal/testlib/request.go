@@ -28,7 +28,7 @@ import (
 type TestRequestContext struct {
 	BaseURL    string
 	SigningKey jwk.RSAPrivateKey
-	VerifyKey  jwk.RSAPublicKey
+	VerifyKeys jwk.Set
 	DB         database.Queryer
 }
 
@@ -38,7 +38,7 @@ func NewTestRequestContext(app *app.Registry) *TestRequestContext {
 	return &TestRequestContext{
 		BaseURL:    app.Config.AppURL,
 		SigningKey: app.SigningKey,
-		VerifyKey:  app.VerifyKey,
+		VerifyKeys: app.VerifyKeys,
 		DB:         app.DB,
 	}
 }
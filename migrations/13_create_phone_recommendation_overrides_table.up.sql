@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS phone_recommendation_overrides (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    phone_id INT NOT NULL,
+    related_phone_id INT NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX phone_recommendation_overrides_pair (phone_id, related_phone_id),
+    FOREIGN KEY (phone_id) REFERENCES phones(id) ON DELETE CASCADE,
+    FOREIGN KEY (related_phone_id) REFERENCES phones(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS accessory_compatibilities;
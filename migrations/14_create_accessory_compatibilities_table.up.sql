@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS accessory_compatibilities (
+    accessory_id INT NOT NULL,
+    phone_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (accessory_id, phone_id),
+    INDEX accessory_compatibilities_phone_id_index (phone_id),
+    FOREIGN KEY (accessory_id) REFERENCES phones(id) ON DELETE CASCADE,
+    FOREIGN KEY (phone_id) REFERENCES phones(id) ON DELETE CASCADE
+);
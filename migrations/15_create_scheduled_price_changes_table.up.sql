@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS scheduled_price_changes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    phone_id INT NOT NULL,
+    price DECIMAL(10, 2) NOT NULL,
+    previous_price DECIMAL(10, 2) NULL DEFAULT NULL,
+    effective_from TIMESTAMP NOT NULL,
+    revert_at TIMESTAMP NULL DEFAULT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    applied_at TIMESTAMP NULL DEFAULT NULL,
+    reverted_at TIMESTAMP NULL DEFAULT NULL,
+    cancelled_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX scheduled_price_changes_status_index (status, effective_from),
+    INDEX scheduled_price_changes_phone_id_index (phone_id),
+    FOREIGN KEY (phone_id) REFERENCES phones(id) ON DELETE CASCADE
+);
@@ -0,0 +1,7 @@
+ALTER TABLE price_history
+    DROP COLUMN campaign_id,
+    DROP COLUMN reason;
+
+DROP TABLE IF EXISTS phone_campaign_prices;
+DROP TABLE IF EXISTS campaign_targets;
+DROP TABLE IF EXISTS campaigns;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS campaigns (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    rule_type VARCHAR(16) NOT NULL,
+    rule_value DECIMAL(10, 2) NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+
+    INDEX campaigns_window_index (starts_at, ends_at)
+);
+
+CREATE TABLE IF NOT EXISTS campaign_targets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    campaign_id INT NOT NULL,
+    target_type VARCHAR(16) NOT NULL,
+    target_id INT NOT NULL,
+
+    UNIQUE INDEX campaign_targets_target (campaign_id, target_type, target_id),
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS phone_campaign_prices (
+    phone_id INT PRIMARY KEY,
+    campaign_id INT NOT NULL,
+    price DECIMAL(10, 2) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (phone_id) REFERENCES phones(id) ON DELETE CASCADE,
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE
+);
+
+ALTER TABLE price_history
+    ADD COLUMN reason VARCHAR(32) NOT NULL DEFAULT 'manual' AFTER new_price,
+    ADD COLUMN campaign_id INT NULL DEFAULT NULL AFTER reason;
@@ -0,0 +1,3 @@
+ALTER TABLE phones DROP COLUMN cost_price;
+
+DROP TABLE IF EXISTS price_rules;
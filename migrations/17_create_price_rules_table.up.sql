@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS price_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    rule_type VARCHAR(16) NOT NULL,
+    value DECIMAL(10, 2) NOT NULL,
+    brand_id INT NULL DEFAULT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (brand_id) REFERENCES brands(id) ON DELETE CASCADE
+);
+
+ALTER TABLE phones ADD COLUMN cost_price DECIMAL(10, 2) NULL DEFAULT NULL AFTER price;
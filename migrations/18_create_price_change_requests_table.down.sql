@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS price_change_requests;
+DROP TABLE IF EXISTS authorities;
+DROP TABLE IF EXISTS permissions;
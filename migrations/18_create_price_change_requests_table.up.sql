@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS permissions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    identifier VARCHAR(191) NOT NULL UNIQUE,
+    module VARCHAR(100),
+    name VARCHAR(255),
+    description TEXT,
+    created_at BIGINT(19),
+    updated_at BIGINT(19)
+);
+
+CREATE TABLE IF NOT EXISTS authorities (
+    id VARCHAR(191) PRIMARY KEY,
+    role_id VARCHAR(191) NOT NULL,
+    permission_id BIGINT NOT NULL,
+    created_at BIGINT(19),
+    updated_at BIGINT(19),
+
+    UNIQUE INDEX authorities_role_permission(role_id, permission_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+INSERT IGNORE INTO permissions (identifier, module, name, description, created_at, updated_at)
+VALUES ('catalog::price.approve', 'catalog', 'Approve price changes', 'Approve or reject price changes waiting for a second admin', UNIX_TIMESTAMP(), UNIX_TIMESTAMP());
+
+CREATE TABLE IF NOT EXISTS price_change_requests (
+    id VARCHAR(191) PRIMARY KEY,
+    phone_id INT NOT NULL,
+    old_price DECIMAL(10, 2) NOT NULL,
+    new_price DECIMAL(10, 2) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    requested_by VARCHAR(191) NOT NULL,
+    reviewed_by VARCHAR(191) NULL DEFAULT NULL,
+    review_note TEXT NULL,
+    reviewed_at BIGINT(19) NULL DEFAULT NULL,
+    created_at BIGINT(19),
+    updated_at BIGINT(19),
+
+    INDEX price_change_requests_status(status, created_at),
+    FOREIGN KEY (phone_id) REFERENCES phones(id) ON DELETE CASCADE
+);
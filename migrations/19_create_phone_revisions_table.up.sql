@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS phone_revisions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    phone_id INT NOT NULL,
+    source VARCHAR(32) NOT NULL,
+    actor_id VARCHAR(191) NULL DEFAULT NULL,
+    changes JSON NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX phone_revisions_phone_id_index (phone_id, created_at),
+    FOREIGN KEY (phone_id) REFERENCES phones(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+ALTER TABLE installments
+    DROP COLUMN three_months_first,
+    DROP COLUMN six_months_first,
+    DROP COLUMN twelve_months_first;
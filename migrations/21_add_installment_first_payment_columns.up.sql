@@ -0,0 +1,16 @@
+ALTER TABLE installments
+    ADD COLUMN three_months_first DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER three_months,
+    ADD COLUMN six_months_first DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER six_months,
+    ADD COLUMN twelve_months_first DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER twelve_months;
+
+-- Monthly amounts are rounded down to the cent, the first payment takes the
+-- remaining cents so every plan adds up to the phone price
+UPDATE installments
+JOIN phones ON phones.id = installments.phone_id
+SET
+    installments.three_months = FLOOR(phones.price * 100 / 3) / 100,
+    installments.three_months_first = phones.price - 2 * FLOOR(phones.price * 100 / 3) / 100,
+    installments.six_months = FLOOR(phones.price * 100 / 6) / 100,
+    installments.six_months_first = phones.price - 5 * FLOOR(phones.price * 100 / 6) / 100,
+    installments.twelve_months = FLOOR(phones.price * 100 / 12) / 100,
+    installments.twelve_months_first = phones.price - 11 * FLOOR(phones.price * 100 / 12) / 100;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS translations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    entity_type VARCHAR(16) NOT NULL,
+    entity_id INT NOT NULL,
+    locale VARCHAR(16) NOT NULL,
+    field VARCHAR(32) NOT NULL,
+    value TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY translations_entity_locale_field_unique (entity_type, entity_id, locale, field),
+    INDEX translations_locale_index (locale, entity_type)
+);
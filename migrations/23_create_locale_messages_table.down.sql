@@ -0,0 +1 @@
+DROP TABLE IF EXISTS locale_messages;
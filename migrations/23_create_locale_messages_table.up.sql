@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS locale_messages (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    locale VARCHAR(16) NOT NULL,
+    message_id VARCHAR(191) NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY locale_messages_locale_message_id_unique (locale, message_id)
+);
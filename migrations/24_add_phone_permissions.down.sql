@@ -0,0 +1 @@
+DELETE FROM permissions WHERE identifier IN ('catalog::phone.create', 'catalog::phone.update');
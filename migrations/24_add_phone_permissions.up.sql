@@ -0,0 +1,11 @@
+INSERT IGNORE INTO permissions (identifier, module, name, description, created_at, updated_at)
+VALUES
+    ('catalog::phone.create', 'catalog', 'Create phones', 'Add phones to the catalog', UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+    ('catalog::phone.update', 'catalog', 'Update phones', 'Edit the phones of the catalog', UNIX_TIMESTAMP(), UNIX_TIMESTAMP());
+
+-- Every role could edit the catalog before these permissions existed
+INSERT IGNORE INTO authorities (id, role_id, permission_id, created_at, updated_at)
+SELECT CONCAT('authorities:', UUID()), roles.id, permissions.id, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()
+FROM roles
+CROSS JOIN permissions
+WHERE permissions.identifier IN ('catalog::phone.create', 'catalog::phone.update');
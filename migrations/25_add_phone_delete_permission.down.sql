@@ -0,0 +1,2 @@
+DELETE authorities FROM authorities JOIN permissions ON permissions.id = authorities.permission_id WHERE permissions.identifier = 'catalog::phone.delete';
+DELETE FROM permissions WHERE identifier = 'catalog::phone.delete';
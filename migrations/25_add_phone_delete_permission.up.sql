@@ -0,0 +1,9 @@
+INSERT IGNORE INTO permissions (identifier, module, name, description, created_at, updated_at)
+VALUES ('catalog::phone.delete', 'catalog', 'Delete phones', 'Remove phones from the catalog', UNIX_TIMESTAMP(), UNIX_TIMESTAMP());
+
+-- Every role could delete phones before the permission existed
+INSERT IGNORE INTO authorities (id, role_id, permission_id, created_at, updated_at)
+SELECT CONCAT('authorities:', UUID()), roles.id, permissions.id, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()
+FROM roles
+CROSS JOIN permissions
+WHERE permissions.identifier = 'catalog::phone.delete';
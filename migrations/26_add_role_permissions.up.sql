@@ -0,0 +1,12 @@
+INSERT IGNORE INTO permissions (identifier, module, name, description, created_at, updated_at)
+VALUES
+    ('admin::index', 'admin', 'List admins', 'See the admins and their roles', UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+    ('admin::role.manage', 'admin', 'Manage roles', 'Create, rename and delete roles and choose their permissions', UNIX_TIMESTAMP(), UNIX_TIMESTAMP());
+
+-- Roles were only managed in the database so far, every existing role gets
+-- the permissions so that an admin can narrow them down through the API
+INSERT IGNORE INTO authorities (id, role_id, permission_id, created_at, updated_at)
+SELECT CONCAT('authorities:', UUID()), roles.id, permissions.id, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()
+FROM roles
+CROSS JOIN permissions
+WHERE permissions.identifier IN ('admin::index', 'admin::role.manage');
@@ -0,0 +1,2 @@
+DELETE authorities FROM authorities JOIN permissions ON permissions.id = authorities.permission_id WHERE permissions.identifier = 'admin::manage';
+DELETE FROM permissions WHERE identifier = 'admin::manage';
@@ -0,0 +1,10 @@
+INSERT IGNORE INTO permissions (identifier, module, name, description, created_at, updated_at)
+VALUES ('admin::manage', 'admin', 'Manage admins', 'Change the role of admins and deactivate or reactivate them', UNIX_TIMESTAMP(), UNIX_TIMESTAMP());
+
+-- Roles allowed to manage roles could already give any admin any permission
+INSERT IGNORE INTO authorities (id, role_id, permission_id, created_at, updated_at)
+SELECT CONCAT('authorities:', UUID()), authorities.role_id, manage.id, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()
+FROM authorities
+JOIN permissions ON permissions.id = authorities.permission_id
+CROSS JOIN permissions manage
+WHERE permissions.identifier = 'admin::role.manage' AND manage.identifier = 'admin::manage';
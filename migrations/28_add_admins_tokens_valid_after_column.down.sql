@@ -0,0 +1 @@
+ALTER TABLE admins DROP COLUMN tokens_valid_after;
@@ -0,0 +1 @@
+ALTER TABLE admins ADD COLUMN tokens_valid_after BIGINT(19) NULL DEFAULT NULL AFTER role_id;
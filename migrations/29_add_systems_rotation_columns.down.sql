@@ -0,0 +1,7 @@
+DELETE authorities FROM authorities JOIN permissions ON permissions.id = authorities.permission_id WHERE permissions.identifier = 'admin::system.manage';
+DELETE FROM permissions WHERE identifier = 'admin::system.manage';
+
+ALTER TABLE systems
+    DROP COLUMN revoked_at,
+    DROP COLUMN previous_secret_expires_at,
+    DROP COLUMN previous_secret_key;
@@ -0,0 +1,14 @@
+ALTER TABLE systems
+    ADD COLUMN previous_secret_key VARCHAR(64) NULL DEFAULT NULL AFTER secret_key,
+    ADD COLUMN previous_secret_expires_at BIGINT(19) NULL DEFAULT NULL AFTER previous_secret_key,
+    ADD COLUMN revoked_at BIGINT(19) NULL DEFAULT NULL AFTER previous_secret_expires_at;
+
+INSERT IGNORE INTO permissions (identifier, module, name, description, created_at, updated_at)
+VALUES ('admin::system.manage', 'admin', 'Manage systems', 'Register partner systems, rotate their secrets and revoke them', UNIX_TIMESTAMP(), UNIX_TIMESTAMP());
+
+INSERT IGNORE INTO authorities (id, role_id, permission_id, created_at, updated_at)
+SELECT CONCAT('authorities:', UUID()), authorities.role_id, manage.id, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()
+FROM authorities
+JOIN permissions ON permissions.id = authorities.permission_id
+CROSS JOIN permissions manage
+WHERE permissions.identifier = 'admin::role.manage' AND manage.identifier = 'admin::system.manage';
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS system_scopes (
+    system_id VARCHAR(191) NOT NULL,
+    scope VARCHAR(64) NOT NULL,
+    created_at BIGINT(19),
+
+    PRIMARY KEY (system_id, scope),
+    FOREIGN KEY (system_id) REFERENCES systems(id) ON DELETE CASCADE
+);
+
+-- Systems could only read the catalog before scopes existed
+INSERT IGNORE INTO system_scopes (system_id, scope, created_at)
+SELECT systems.id, scopes.scope, UNIX_TIMESTAMP()
+FROM systems
+CROSS JOIN (SELECT 'catalog:read' AS scope UNION ALL SELECT 'prices:read') scopes;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS admin_refresh_tokens (
+    id VARCHAR(191) PRIMARY KEY,
+    admin_id VARCHAR(191) NOT NULL,
+    family_id VARCHAR(191) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    access_token_id VARCHAR(191) NOT NULL,
+    expires_at BIGINT(19) NOT NULL,
+    used_at BIGINT(19) NULL DEFAULT NULL,
+    revoked_at BIGINT(19) NULL DEFAULT NULL,
+    created_at BIGINT(19),
+    updated_at BIGINT(19),
+
+    UNIQUE INDEX admin_refresh_tokens_token_hash(token_hash),
+    INDEX admin_refresh_tokens_family_id(family_id),
+    INDEX admin_refresh_tokens_admin_id(admin_id),
+    INDEX admin_refresh_tokens_access_token_id(access_token_id),
+    FOREIGN KEY (admin_id) REFERENCES admins(id)
+);
@@ -0,0 +1 @@
+ALTER TABLE admin_access_tokens DROP COLUMN request_fingerprint;
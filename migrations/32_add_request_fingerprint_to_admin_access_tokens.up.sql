@@ -0,0 +1 @@
+ALTER TABLE admin_access_tokens ADD COLUMN request_fingerprint TEXT NULL DEFAULT NULL AFTER expired_at;
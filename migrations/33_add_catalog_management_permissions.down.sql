@@ -0,0 +1,2 @@
+DELETE authorities FROM authorities JOIN permissions ON permissions.id = authorities.permission_id WHERE permissions.identifier IN ('admin::locale.manage', 'catalog::campaign.manage', 'catalog::price_rule.manage', 'catalog::exchange_rate.manage', 'catalog::translation.manage');
+DELETE FROM permissions WHERE identifier IN ('admin::locale.manage', 'catalog::campaign.manage', 'catalog::price_rule.manage', 'catalog::exchange_rate.manage', 'catalog::translation.manage');
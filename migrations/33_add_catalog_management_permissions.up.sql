@@ -0,0 +1,14 @@
+INSERT IGNORE INTO permissions (identifier, module, name, description, created_at, updated_at)
+VALUES
+    ('admin::locale.manage', 'admin', 'Manage locales', 'Override the interface messages of the locales', UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+    ('catalog::campaign.manage', 'catalog', 'Manage campaigns', 'Create, edit and delete price campaigns', UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+    ('catalog::price_rule.manage', 'catalog', 'Manage price rules', 'Create, edit, preview and delete the rules applied to phone prices', UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+    ('catalog::exchange_rate.manage', 'catalog', 'Manage exchange rates', 'Set and delete the exchange rates used for display prices', UNIX_TIMESTAMP(), UNIX_TIMESTAMP()),
+    ('catalog::translation.manage', 'catalog', 'Manage translations', 'Edit and delete the translations of catalog entries', UNIX_TIMESTAMP(), UNIX_TIMESTAMP());
+
+-- Every role could do all of this before these permissions existed
+INSERT IGNORE INTO authorities (id, role_id, permission_id, created_at, updated_at)
+SELECT CONCAT('authorities:', UUID()), roles.id, permissions.id, UNIX_TIMESTAMP(), UNIX_TIMESTAMP()
+FROM roles
+CROSS JOIN permissions
+WHERE permissions.identifier IN ('admin::locale.manage', 'catalog::campaign.manage', 'catalog::price_rule.manage', 'catalog::exchange_rate.manage', 'catalog::translation.manage');
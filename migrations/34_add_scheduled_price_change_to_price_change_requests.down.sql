@@ -0,0 +1,3 @@
+ALTER TABLE price_change_requests
+    DROP FOREIGN KEY fk_price_change_requests_scheduled_price_change,
+    DROP COLUMN scheduled_price_change_id;
@@ -0,0 +1,3 @@
+ALTER TABLE price_change_requests
+    ADD COLUMN scheduled_price_change_id INT NULL DEFAULT NULL AFTER phone_id,
+    ADD CONSTRAINT fk_price_change_requests_scheduled_price_change FOREIGN KEY (scheduled_price_change_id) REFERENCES scheduled_price_changes(id) ON DELETE CASCADE;
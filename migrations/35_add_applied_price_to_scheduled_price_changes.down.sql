@@ -0,0 +1 @@
+ALTER TABLE scheduled_price_changes DROP COLUMN applied_price;
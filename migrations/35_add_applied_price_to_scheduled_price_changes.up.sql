@@ -0,0 +1,2 @@
+ALTER TABLE scheduled_price_changes
+    ADD COLUMN applied_price DECIMAL(10, 2) NULL DEFAULT NULL AFTER previous_price;
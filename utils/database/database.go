@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/base64"
 	"errors"
@@ -29,6 +30,33 @@ type TxQueryer interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 }
 
+// WithAdvisoryLock runs fn while holding the MySQL named lock name, so only one
+// instance sharing the database runs it at a time. It does not wait for the
+// lock: when another session holds it, fn is skipped and ok is false.
+func WithAdvisoryLock(db *sqlx.DB, name string, fn func() error) (ok bool, err error) {
+	ctx := context.Background()
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[WithAdvisoryLock][Connx]%w", err)
+	}
+	defer conn.Close()
+
+	var acquired sql.NullInt64
+	if err := conn.GetContext(ctx, &acquired, "SELECT GET_LOCK(?, 0)", name); err != nil {
+		return false, fmt.Errorf("[WithAdvisoryLock][GetLock]%w", err)
+	}
+	if acquired.Int64 != 1 {
+		return false, nil
+	}
+	defer func() {
+		if _, releaseErr := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", name); releaseErr != nil && err == nil {
+			err = fmt.Errorf("[WithAdvisoryLock][ReleaseLock]%w", releaseErr)
+		}
+	}()
+
+	return true, fn()
+}
+
 type LimitOffsetCursor struct {
 	Limit  int
 	Offset int
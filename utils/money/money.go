@@ -0,0 +1,227 @@
+// Package money represents prices exactly, as an integer amount of minor
+// units, so sums, splits and percentages never drift the way float64 does.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount is an amount of money in cents. Catalog prices are DECIMAL(10, 2)
+// columns, so two decimal places are always enough.
+type Amount int64
+
+const scale = 100
+
+// RoundingMode tells the operations that cannot be exact which way to go.
+type RoundingMode int
+
+const (
+	// HalfUp rounds to the nearest cent, halves away from zero.
+	HalfUp RoundingMode = iota
+	// HalfEven rounds to the nearest cent, halves to the even cent.
+	HalfEven
+	// Down rounds toward zero.
+	Down
+	// Up rounds away from zero.
+	Up
+)
+
+var ErrInvalidAmount = errors.New("money: invalid amount")
+
+func FromCents(cents int64) Amount {
+	return Amount(cents)
+}
+
+// FromInt returns an amount of whole units, FromInt(5) is 5.00.
+func FromInt(units int64) Amount {
+	return Amount(units * scale)
+}
+
+// FromFloat converts the shortest decimal form of f, so 0.1 is 0.10 and not
+// the binary value closest to it.
+func FromFloat(f float64, mode RoundingMode) Amount {
+	return fromRat(decimalRat(f), mode)
+}
+
+// Parse reads a decimal string such as "12990", "12990.5" or "-3.25". More
+// than two decimal places is refused rather than silently rounded.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || strings.ContainsAny(s, "/eE") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r.Mul(r, big.NewRat(scale, 1))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	return Amount(r.Num().Int64()), nil
+}
+
+func (a Amount) Cents() int64 {
+	return int64(a)
+}
+
+// Float64 is only meant for ratios and display, never for further money
+// arithmetic.
+func (a Amount) Float64() float64 {
+	return float64(a) / scale
+}
+
+func (a Amount) String() string {
+	sign := ""
+	cents := int64(a)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/scale, cents%scale)
+}
+
+// Mul multiplies the amount by factor, for example 1.05 for a 5% increase.
+func (a Amount) Mul(factor float64, mode RoundingMode) Amount {
+	r := decimalRat(factor)
+	return fromRat(r.Mul(r, big.NewRat(int64(a), scale)), mode)
+}
+
+// Percent returns percent % of the amount.
+func (a Amount) Percent(percent float64, mode RoundingMode) Amount {
+	r := decimalRat(percent)
+	return fromRat(r.Mul(r, big.NewRat(int64(a), scale*100)), mode)
+}
+
+// Div divides the amount in n.
+func (a Amount) Div(n int64, mode RoundingMode) Amount {
+	return fromRat(big.NewRat(int64(a), scale*n), mode)
+}
+
+// Split divides the amount into n payments which add up to it exactly. Every
+// payment is rest, except the first one which also takes the cents left over.
+func (a Amount) Split(n int64) (first, rest Amount) {
+	if n <= 0 {
+		return a, 0
+	}
+	rest = a.Div(n, Down)
+	return a - rest*Amount(n-1), rest
+}
+
+// RoundTo rounds the amount to a multiple of step, which has to be positive.
+func (a Amount) RoundTo(step Amount, mode RoundingMode) Amount {
+	if step <= 0 {
+		return a
+	}
+	return fromRat(big.NewRat(int64(a), int64(step)*scale), mode) * step
+}
+
+func Min(a, b Amount) Amount {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func Max(a, b Amount) Amount {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// decimalRat is the exact value of the shortest decimal form of f.
+func decimalRat(f float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+// fromRat rounds r, a number of units, to cents.
+func fromRat(r *big.Rat, mode RoundingMode) Amount {
+	cents := new(big.Rat).Mul(r, big.NewRat(scale, 1))
+	quo, rem := new(big.Int).QuoRem(cents.Num(), cents.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return Amount(quo.Int64())
+	}
+
+	sign := int64(cents.Sign())
+	// Compare twice the remainder with the denominator to find halves
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(cents.Denom())
+
+	awayFromZero := false
+	switch mode {
+	case HalfUp:
+		awayFromZero = half >= 0
+	case HalfEven:
+		awayFromZero = half > 0 || (half == 0 && quo.Bit(0) == 1)
+	case Up:
+		awayFromZero = true
+	case Down:
+		awayFromZero = false
+	}
+	if awayFromZero {
+		return Amount(quo.Int64() + sign)
+	}
+	return Amount(quo.Int64())
+}
+
+// Scan reads DECIMAL columns, which the MySQL driver returns as text.
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case []byte:
+		return a.parseInto(string(v))
+	case string:
+		return a.parseInto(v)
+	case int64:
+		*a = FromInt(v)
+		return nil
+	case float64:
+		*a = FromFloat(v, HalfUp)
+		return nil
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+}
+
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// MarshalJSON writes the amount as a decimal string, "12990.00", so clients
+// never parse it into a float by accident.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts both a decimal string and a JSON number.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	return a.parseInto(s)
+}
+
+func (a *Amount) parseInto(s string) error {
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
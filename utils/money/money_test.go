@@ -0,0 +1,88 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+		err  bool
+	}{
+		{"12990", 1299000, false},
+		{"12990.5", 1299050, false},
+		{"-3.25", -325, false},
+		{"0.001", 0, true},
+		{"1e3", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("Parse(%q): want %v, error %v; got %v, %v", tt.in, tt.want, tt.err, got, err)
+		}
+	}
+}
+
+func TestRounding(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Amount
+		want Amount
+	}{
+		{"half up", FromInt(10).Div(8, HalfUp), 125},
+		{"half up on half cent", FromCents(5).Percent(50, HalfUp), 3},
+		{"half even on half cent", FromCents(5).Percent(50, HalfEven), 2},
+		{"half even odd", FromCents(15).Percent(50, HalfEven), 8},
+		{"down", FromInt(10000).Div(3, Down), 333333},
+		{"up", FromInt(10000).Div(3, Up), 333334},
+		{"negative half up", FromCents(-5).Percent(50, HalfUp), -3},
+		{"mul", FromInt(999).Mul(1.05, HalfUp), 104895},
+		{"from float", FromFloat(0.1+0.2, HalfUp), 30},
+		{"round to step", FromCents(994202650).RoundTo(FromInt(1000), HalfUp), FromInt(9942000)},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: want %v; got %v", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for _, n := range []int64{3, 6, 12} {
+		price := FromInt(10000)
+		first, rest := price.Split(n)
+		if first+rest*Amount(n-1) != price {
+			t.Errorf("Split(%d): %v + %d x %v does not add up to %v", n, first, n-1, rest, price)
+		}
+		if first < rest || first-rest >= Amount(n) {
+			t.Errorf("Split(%d): unexpected first payment %v for %v", n, first, rest)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		A Amount  `json:"a"`
+		B Amount  `json:"b"`
+		C *Amount `json:"c"`
+	}
+	if err := json.Unmarshal([]byte(`{"a": 12990.5, "b": "3.25", "c": null}`), &v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.A != 1299050 || v.B != 325 || v.C != nil {
+		t.Errorf("unexpected values: %+v", v)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != `{"a":"12990.50","b":"3.25","c":null}` {
+		t.Errorf("unexpected JSON: %s", out)
+	}
+}
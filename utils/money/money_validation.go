@@ -0,0 +1,36 @@
+package money
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// ozzo-validation reads a driver.Valuer through its Value, the decimal string
+// of an Amount, so the built-in Min and Required rules cannot be used on it.
+
+// Positive checks that an Amount, or a non-nil *Amount, is above zero.
+func Positive(value interface{}) error {
+	if a, ok := amountOf(value); ok && a <= 0 {
+		return validation.ErrMinGreaterThanRequired.SetParams(map[string]any{"threshold": 0})
+	}
+	return nil
+}
+
+// NotNegative checks that an Amount, or a non-nil *Amount, is zero or more.
+func NotNegative(value interface{}) error {
+	if a, ok := amountOf(value); ok && a < 0 {
+		return validation.ErrMinGreaterEqualThanRequired.SetParams(map[string]any{"threshold": 0})
+	}
+	return nil
+}
+
+func amountOf(value interface{}) (Amount, bool) {
+	switch v := value.(type) {
+	case Amount:
+		return v, true
+	case *Amount:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return 0, false
+}